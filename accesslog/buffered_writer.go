@@ -0,0 +1,277 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Default values used by BufferedFileWriter.
+const (
+	DefaultQueueSize     = 4096
+	DefaultFlushInterval = time.Second
+)
+
+// maxBufferSize is the number of bytes buffered by BufferedFileWriter
+// before they are written to the log file.
+const maxBufferSize = 64 * 1024
+
+// BufferedFileWriter implements logger.Adapter and writes access logs
+// to a file path. In contrast to FileWriter it keeps the file open and
+// performs all writes on a background goroutine that is fed by a
+// bounded in-memory queue. If the queue is full new log messages are
+// dropped and counted. Buffered data is flushed to the file every
+// FlushInterval and when Close is called. Use Reopen to re-open the
+// log file after it has been moved by external tools like logrotate.
+type BufferedFileWriter struct {
+	// Path is the path of the log file.
+	Path string
+
+	// ErrorAdapter is used to report errors when writing to
+	// the log file.
+	ErrorAdapter logger.Adapter
+
+	// QueueSize is the maximum number of log messages that
+	// are queued. Defaults to DefaultQueueSize.
+	QueueSize int
+
+	// FlushInterval configures how often buffered data is flushed
+	// to the file. Defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// Rotation configures if and when the log file
+	// should be rotated.
+	Rotation RotationPolicy
+
+	// Format is used to encode log messages. If nil, log
+	// messages are encoded using FormatJSON.
+	Format Formatter
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	closed    int32
+	dropped   uint64
+
+	queue     chan []byte
+	flushReq  chan chan struct{}
+	reopenReq chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+
+	// only accessed by the background goroutine.
+	file     *logFile
+	buf      bytes.Buffer
+	reported uint64
+}
+
+// Write is called for each log message and implements logger.Adapter.
+// It never blocks.
+func (bw *BufferedFileWriter) Write(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+	bw.startOnce.Do(bw.start)
+
+	if atomic.LoadInt32(&bw.closed) == 1 {
+		atomic.AddUint64(&bw.dropped, 1)
+		return
+	}
+
+	select {
+	case bw.queue <- formatEntry(bw.Format, clock, severity, msg, fields):
+	default:
+		atomic.AddUint64(&bw.dropped, 1)
+	}
+}
+
+// Dropped returns the number of log messages that have been dropped
+// because the queue was full or the writer was already closed.
+func (bw *BufferedFileWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&bw.dropped)
+}
+
+// Flush writes all queued and buffered log messages to the file.
+// It blocks until all data is written or ctx is cancelled.
+func (bw *BufferedFileWriter) Flush(ctx context.Context) error {
+	bw.startOnce.Do(bw.start)
+
+	ack := make(chan struct{})
+	select {
+	case bw.flushReq <- ack:
+	case <-bw.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reopen flushes all buffered data and closes the log file. The
+// file is re-opened when the next log message is written. Reopen
+// does not block.
+func (bw *BufferedFileWriter) Reopen() {
+	bw.startOnce.Do(bw.start)
+
+	select {
+	case bw.reopenReq <- struct{}{}:
+	default:
+		// a reopen is already pending
+	}
+}
+
+// Close flushes all queued log messages and closes the file. Log
+// messages written after Close has been called are dropped. If ctx
+// is cancelled before all messages are written, the returned error
+// contains the number of messages still queued; they are written
+// in the background. Close is safe to be called multiple times.
+func (bw *BufferedFileWriter) Close(ctx context.Context) error {
+	bw.startOnce.Do(bw.start)
+
+	bw.closeOnce.Do(func() {
+		atomic.StoreInt32(&bw.closed, 1)
+		close(bw.stop)
+	})
+
+	select {
+	case <-bw.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d log messages not written: %w", len(bw.queue), ctx.Err())
+	}
+}
+
+func (bw *BufferedFileWriter) start() {
+	size := bw.QueueSize
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	bw.queue = make(chan []byte, size)
+	bw.flushReq = make(chan chan struct{})
+	bw.reopenReq = make(chan struct{}, 1)
+	bw.file = &logFile{
+		path:         bw.Path,
+		policy:       bw.Rotation,
+		errorAdapter: bw.ErrorAdapter,
+	}
+	bw.stop = make(chan struct{})
+	bw.done = make(chan struct{})
+
+	go bw.run()
+}
+
+func (bw *BufferedFileWriter) run() {
+	defer close(bw.done)
+
+	interval := bw.FlushInterval
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case blob := <-bw.queue:
+			bw.write(blob)
+
+		case <-ticker.C:
+			bw.flush()
+
+		case ack := <-bw.flushReq:
+			bw.drain()
+			bw.flush()
+			close(ack)
+
+		case <-bw.reopenReq:
+			bw.drain()
+			bw.flush()
+			bw.closeFile()
+
+		case <-bw.stop:
+			bw.drain()
+			bw.flush()
+			bw.closeFile()
+			bw.file.milling.Wait()
+			return
+		}
+	}
+}
+
+// drain writes all log messages that are currently queued.
+func (bw *BufferedFileWriter) drain() {
+	for {
+		select {
+		case blob := <-bw.queue:
+			bw.write(blob)
+		default:
+			return
+		}
+	}
+}
+
+func (bw *BufferedFileWriter) write(blob []byte) {
+	// make sure we only ever write complete lines to the
+	// log file so they are not split during rotation.
+	if bw.buf.Len() > 0 && bw.buf.Len()+len(blob) > maxBufferSize {
+		bw.writeBuffer()
+	}
+
+	bw.buf.Write(blob)
+}
+
+func (bw *BufferedFileWriter) flush() {
+	bw.writeBuffer()
+
+	// report dropped messages so there's at least a chance of
+	// a user noticing that the log is incomplete.
+	if dropped := bw.Dropped(); dropped != bw.reported {
+		bw.reportError("access log queue full, dropped log messages", logger.Fields{
+			"dropped": dropped - bw.reported,
+			"total":   dropped,
+			"path":    bw.Path,
+		})
+		bw.reported = dropped
+	}
+}
+
+func (bw *BufferedFileWriter) writeBuffer() {
+	if bw.buf.Len() == 0 {
+		return
+	}
+	defer bw.buf.Reset()
+
+	n, err := bw.file.Write(bw.buf.Bytes())
+	if err != nil || n != bw.buf.Len() {
+		reportIncompleteWrite(bw.errorAdapter(), bw.Path, bw.buf.Len(), n, err)
+	}
+}
+
+func (bw *BufferedFileWriter) closeFile() {
+	if err := bw.file.Close(); err != nil {
+		bw.reportError("failed to close log file", logger.Fields{
+			"error": err.Error(),
+			"path":  bw.Path,
+		})
+	}
+}
+
+func (bw *BufferedFileWriter) reportError(msg string, fields logger.Fields) {
+	bw.errorAdapter().Write(time.Now(), logger.Error, msg, fields)
+}
+
+func (bw *BufferedFileWriter) errorAdapter() logger.Adapter {
+	if bw.ErrorAdapter == nil {
+		return logger.DefaultAdapter()
+	}
+	return bw.ErrorAdapter
+}
@@ -0,0 +1,176 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tierklinik-dobersberg/service/utils"
+)
+
+// QueryCommand implements a command line interface for querying
+// access log files written using FormatJSON or FormatJSONFlat.
+// args are the command line arguments without the program or
+// sub-command name. Results are written to stdout. Services may
+// expose QueryCommand as a sub-command:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "accesslog" {
+//	    if err := accesslog.QueryCommand(os.Args[2:], os.Stdout); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    return
+//	}
+func QueryCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("accesslog", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.Usage = func() {
+		fmt.Fprintf(stdout, "Usage: accesslog [flags] <access-log-file>...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	var (
+		since      = flags.String("since", "", "Only include requests since `time` (RFC3339 or a duration like 24h)")
+		until      = flags.String("until", "", "Only include requests before `time` (RFC3339 or a duration like 1h)")
+		status     = flags.String("status", "", "Only include requests with `status` (like 404 or 5xx)")
+		pathFilter = flags.String("path", "", "Only include requests matching the `pattern` (a route, a path pattern or a prefix ending in /**)")
+		ips        = flags.String("ip", "", "Only include requests from comma separated `IPs or CIDRs`")
+		minLatency = flags.Duration("min-latency", 0, "Only include requests that took at least `duration`")
+		maxLatency = flags.Duration("max-latency", 0, "Only include requests that took at most `duration`")
+		top        = flags.Int("top", 10, "Number of routes and client IPs to print")
+		rotated    = flags.Bool("rotated", true, "Include rotated (and compressed) log files")
+		asJSON     = flags.Bool("json", false, "Print the report as JSON")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no access log file specified")
+	}
+
+	filter := Filter{
+		Path:       *pathFilter,
+		MinLatency: *minLatency,
+		MaxLatency: *maxLatency,
+	}
+
+	var err error
+	if filter.Since, err = parseTimeFlag(*since); err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+	if filter.Until, err = parseTimeFlag(*until); err != nil {
+		return fmt.Errorf("-until: %w", err)
+	}
+	if filter.Status, filter.StatusClass, err = parseStatusFlag(*status); err != nil {
+		return fmt.Errorf("-status: %w", err)
+	}
+	if *ips != "" {
+		if filter.Networks, err = utils.ParseNetworks(strings.Split(*ips, ",")); err != nil {
+			return fmt.Errorf("-ip: %w", err)
+		}
+	}
+
+	var files []string
+	for _, p := range flags.Args() {
+		if !*rotated {
+			files = append(files, p)
+			continue
+		}
+
+		all, err := LogFiles(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, all...)
+	}
+
+	stats := NewStats()
+	for _, file := range files {
+		err := ReadFile(file, func(e *Entry) error {
+			if filter.Matches(e) {
+				stats.Add(e)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	report := stats.Report(*top)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	return printReport(stdout, report)
+}
+
+func printReport(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Requests:\t%d\n", report.Total)
+	fmt.Fprintf(tw, "Latency p50:\t%s\n", report.P50)
+	fmt.Fprintf(tw, "Latency p95:\t%s\n", report.P95)
+	fmt.Fprintf(tw, "Latency p99:\t%s\n", report.P99)
+
+	fmt.Fprintf(tw, "\nStatus\tRequests\n")
+	statuses := make([]int, 0, len(report.Statuses))
+	for s := range report.Statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Ints(statuses)
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%d\t%d\n", s, report.Statuses[s])
+	}
+
+	fmt.Fprintf(tw, "\nRoute\tRequests\n")
+	for _, c := range report.Routes {
+		fmt.Fprintf(tw, "%s\t%d\n", c.Key, c.Count)
+	}
+
+	fmt.Fprintf(tw, "\nClient IP\tRequests\n")
+	for _, c := range report.ClientIPs {
+		fmt.Fprintf(tw, "%s\t%d\n", c.Key, c.Count)
+	}
+
+	return tw.Flush()
+}
+
+// parseTimeFlag parses s either as a RFC3339 timestamp or a
+// duration relative to now.
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseStatusFlag parses s either as a status code (404) or a
+// status class (4xx).
+func parseStatusFlag(s string) (status int, class int, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		class, err = strconv.Atoi(s[:1])
+		return 0, class, err
+	}
+
+	status, err = strconv.Atoi(s)
+	return status, 0, err
+}
@@ -0,0 +1,213 @@
+package accesslog
+
+import (
+	"time"
+
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Config holds the configuration of the access log as read
+// from the [AccessLog] section.
+type Config struct {
+	// Path is the path to the access log file. If empty, access
+	// logs are only written to the default logger.
+	Path string
+
+	// MaxSize is the maximum size of the access log in megabytes
+	// before it is rotated.
+	MaxSize int
+
+	// RotateDaily enables daily rotation of the access log.
+	RotateDaily bool
+
+	// MaxAge is the maximum age of rotated access log files.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of rotated access log
+	// files to keep.
+	MaxBackups int
+
+	// Compress enables gzip compression of rotated access
+	// log files.
+	Compress bool
+
+	// Format is the name of the access log format. See
+	// NewFormatter for supported values.
+	Format string
+
+	// Template is the text/template used when Format is
+	// set to FormatTemplate.
+	Template string
+
+	// RedactQuery holds additional query parameters that
+	// should be redacted.
+	RedactQuery []string
+
+	// RedactHeaders holds additional headers that should be
+	// redacted.
+	RedactHeaders []string
+
+	// RedactFields holds log fields that should be redacted.
+	RedactFields []string
+
+	// RequestHeaders holds request headers that should be logged.
+	RequestHeaders []string
+
+	// ResponseHeaders holds response headers that should be logged.
+	ResponseHeaders []string
+
+	// Rules holds access log rules in the format supported by
+	// ParseRule.
+	Rules []string `option:"Rule"`
+
+	// SlowThreshold is the latency threshold above which requests
+	// are always logged and reported as slow.
+	SlowThreshold time.Duration
+
+	// SlowRoutes holds per-route slow request thresholds in the
+	// format supported by SlowRequests.ParseThreshold.
+	SlowRoutes []string `option:"SlowRoute"`
+}
+
+// SlowRequests returns a new slow request detector that reports
+// to log using the thresholds configured in cfg.
+func (cfg Config) SlowRequests(log logger.Logger) (*SlowRequests, error) {
+	s := NewSlowRequests(log, cfg.SlowThreshold)
+	for _, spec := range cfg.SlowRoutes {
+		if err := s.ParseThreshold(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Options returns the request logger options configured in cfg.
+func (cfg Config) Options() ([]Option, error) {
+	rules := make([]*Rule, len(cfg.Rules))
+	for idx, s := range cfg.Rules {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		rules[idx] = r
+	}
+
+	return []Option{
+		WithRedactedQuery(cfg.RedactQuery...),
+		WithRedactedHeaders(cfg.RedactHeaders...),
+		WithRedactedFields(cfg.RedactFields...),
+		WithRequestHeaders(cfg.RequestHeaders...),
+		WithResponseHeaders(cfg.ResponseHeaders...),
+		WithRules(rules...),
+		WithSlowThreshold(cfg.SlowThreshold),
+	}, nil
+}
+
+// Formatter returns the access log formatter configured
+// in cfg.
+func (cfg Config) Formatter() (Formatter, error) {
+	return NewFormatter(cfg.Format, cfg.Template)
+}
+
+// RotationPolicy returns the rotation policy configured in cfg.
+func (cfg Config) RotationPolicy() RotationPolicy {
+	return RotationPolicy{
+		MaxSize:    int64(cfg.MaxSize) * 1024 * 1024,
+		Daily:      cfg.RotateDaily,
+		MaxAge:     cfg.MaxAge,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+	}
+}
+
+// ConfigSpec defines the available configuration values for the
+// [AccessLog] section.
+var ConfigSpec = conf.SectionSpec{
+	{
+		Name:        "Path",
+		Description: "Path to the access log file. Overwrites AccessLogPath.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "MaxSize",
+		Description: "Maximum size of the access log in megabytes before it is rotated. 0 disables size based rotation.",
+		Type:        conf.IntType,
+		Default:     "0",
+	},
+	{
+		Name:        "RotateDaily",
+		Description: "Whether or not the access log should be rotated each day.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "MaxAge",
+		Description: "Maximum age of rotated access log files. Older files are removed.",
+		Type:        conf.DurationType,
+	},
+	{
+		Name:        "MaxBackups",
+		Description: "Maximum number of rotated access log files to keep.",
+		Type:        conf.IntType,
+		Default:     "0",
+	},
+	{
+		Name:        "Compress",
+		Description: "Whether or not rotated access log files should be compressed using gzip.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "Format",
+		Description: "Format of the access log file. One of json, json-flat, common, combined, logfmt or template.",
+		Type:        conf.StringType,
+		Default:     FormatJSON,
+	},
+	{
+		Name:        "Template",
+		Description: "A text/template used for Format=template. Use {{ .Field \"http:status\" }} to access log fields.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "RedactQuery",
+		Description: "Additional query parameters whose values should be redacted. access_token, api_key, apikey, password, secret and token are always redacted.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "RedactHeaders",
+		Description: "Additional headers whose values should be redacted. Authorization, Cookie, Proxy-Authorization and Set-Cookie are always redacted.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "RedactFields",
+		Description: "Log fields whose values should be redacted.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "RequestHeaders",
+		Description: "Request headers that should be logged as http:request-header:<name>.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "ResponseHeaders",
+		Description: "Response headers that should be logged as http:response-header:<name>.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "Rule",
+		Description: "Rules to skip (\"skip GET /healthz\"), sample (\"sample:100 /static/**\") or always log (\"log status=4xx\", \"log latency>=1s\") requests. The first matching rule wins. Requests with a 5xx status code are always logged.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "SlowThreshold",
+		Description: "Requests that take longer than SlowThreshold are always logged and reported as slow requests with warning level.",
+		Type:        conf.DurationType,
+	},
+	{
+		Name:        "SlowRoute",
+		Description: "Per-route slow request threshold in the format \"[METHOD] <route> <duration>\" (like \"GET /patients/:id 500ms\"). Overwrites SlowThreshold for the route.",
+		Type:        conf.StringSliceType,
+	},
+}
@@ -0,0 +1,68 @@
+package accesslog
+
+// Stable names of the fields added to each access log entry by
+// the request logger returned by New. Log formats, filters and
+// the query command rely on these names.
+const (
+	// FieldStatus is the HTTP status code of the response.
+	FieldStatus = "http:status"
+
+	// FieldMethod is the HTTP method of the request.
+	FieldMethod = "http:method"
+
+	// FieldProto is the protocol of the request (like HTTP/1.1).
+	FieldProto = "http:proto"
+
+	// FieldPath is the request path including the (redacted)
+	// query string.
+	FieldPath = "http:path"
+
+	// FieldRoute is the gin route template that matched the
+	// request (like /patients/:id). It is empty if no route
+	// matched.
+	FieldRoute = "http:route"
+
+	// FieldHandler is the name of the gin handler function that
+	// handled the request.
+	FieldHandler = "http:handler"
+
+	// FieldListener is the name of the listener that accepted
+	// the request. See WithListenerName.
+	FieldListener = "http:listener"
+
+	// FieldRemoteAddr is the remote address of the connection.
+	FieldRemoteAddr = "http:remote-addr"
+
+	// FieldRealIP is the IP address of the client, taking trusted
+	// proxy headers into account.
+	FieldRealIP = "http:real-ip"
+
+	// FieldLatency is the request latency as a human readable
+	// string.
+	FieldLatency = "http:latency"
+
+	// FieldLatencyRaw is the request latency as a time.Duration.
+	FieldLatencyRaw = "http:latency-raw"
+
+	// FieldUserAgent is the User-Agent header of the request.
+	FieldUserAgent = "http:user-agent"
+
+	// FieldReferer is the Referer header of the request. It is
+	// only set if the request has a Referer header.
+	FieldReferer = "http:referer"
+
+	// FieldResponseSize is the size of the response body in bytes.
+	FieldResponseSize = "http:response-size"
+
+	// FieldRequestHeaderPrefix prefixes request headers logged
+	// using WithRequestHeaders.
+	FieldRequestHeaderPrefix = "http:request-header:"
+
+	// FieldResponseHeaderPrefix prefixes response headers logged
+	// using WithResponseHeaders.
+	FieldResponseHeaderPrefix = "http:response-header:"
+
+	// FieldThreshold is the slow request threshold that has been
+	// exceeded. It is only set on slow request warnings.
+	FieldThreshold = "http:threshold"
+)
@@ -2,23 +2,46 @@ package accesslog
 
 import (
 	"encoding/json"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/tierklinik-dobersberg/logger"
 )
 
 // FileWriter implements logger.Adapter and writes access logs
-// to a file path.
+// to a file path. Note that FileWriter opens and closes the file
+// for each log message. Use BufferedFileWriter for services that
+// handle a lot of requests.
 type FileWriter struct {
 	Path         string
 	ErrorAdapter logger.Adapter
+
+	// Rotation configures if and when the log file
+	// should be rotated.
+	Rotation RotationPolicy
+
+	// Format is used to encode log messages. If nil, log
+	// messages are encoded using FormatJSON.
+	Format Formatter
+
+	l    sync.Mutex
+	file *logFile
 }
 
 // Write is called for each log message and implements logger.Adapter.
 func (fl *FileWriter) Write(clock time.Time, severtiy logger.Severity, msg string, fields logger.Fields) {
-	f, err := os.OpenFile(fl.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
+	fl.l.Lock()
+	defer fl.l.Unlock()
+
+	if fl.file == nil {
+		fl.file = &logFile{
+			path:         fl.Path,
+			policy:       fl.Rotation,
+			errorAdapter: fl.ErrorAdapter,
+		}
+	}
+
+	if err := fl.file.open(); err != nil {
 		// Create a new StdlibAdapter and write a warning message there
 		// so there's at least a chance of a user noticing the file-log
 		// doesn't work.
@@ -28,11 +51,31 @@ func (fl *FileWriter) Write(clock time.Time, severtiy logger.Severity, msg strin
 		})
 		return
 	}
-	defer f.Close()
+	defer fl.file.Close()
+
+	blob := formatEntry(fl.Format, clock, severtiy, msg, fields)
 
+	n, err := fl.file.Write(blob)
+	if err != nil || n != len(blob) {
+		reportIncompleteWrite(fl.ErrorAdapter, fl.Path, len(blob), n, err)
+	}
+}
+
+// formatEntry formats a log message using format. If format is nil
+// the log message is encoded using encodeEntry.
+func formatEntry(format Formatter, clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+	if format == nil {
+		return encodeEntry(clock, severity, msg, fields)
+	}
+
+	return format.Format(clock, severity, msg, fields)
+}
+
+// encodeEntry encodes a log message as a JSON line.
+func encodeEntry(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
 	obj := map[string]interface{}{
 		"time":     clock,
-		"severity": severtiy,
+		"severity": severity,
 		"msg":      msg,
 		"fields":   fields,
 	}
@@ -42,19 +85,19 @@ func (fl *FileWriter) Write(clock time.Time, severtiy logger.Severity, msg strin
 		obj["fields"] = err.Error()
 		blob, _ = json.Marshal(obj)
 	}
-	blob = append(blob, '\n')
 
-	n, err := f.Write(blob)
-	if err != nil || n != len(blob) {
-		errMsg := "<nil>"
-		if err != nil {
-			errMsg = err.Error()
-		}
-		fl.ErrorAdapter.Write(time.Now(), logger.Error, "incomplete write to log file", logger.Fields{
-			"error":        errMsg,
-			"expectedSize": len(blob),
-			"bytesWritten": n,
-			"path":         fl.Path,
-		})
+	return append(blob, '\n')
+}
+
+func reportIncompleteWrite(adapter logger.Adapter, path string, expected, written int, err error) {
+	errMsg := "<nil>"
+	if err != nil {
+		errMsg = err.Error()
 	}
+	adapter.Write(time.Now(), logger.Error, "incomplete write to log file", logger.Fields{
+		"error":        errMsg,
+		"expectedSize": expected,
+		"bytesWritten": written,
+		"path":         path,
+	})
 }
@@ -0,0 +1,84 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+var benchFields = logger.Fields{
+	"http:status":      200,
+	"http:method":      "GET",
+	"http:path":        "/api/v1/patients/123",
+	"http:remote-addr": "10.1.1.1:4123",
+	"http:latency":     "1.2ms",
+	"http:user-agent":  "Mozilla/5.0",
+}
+
+func Test_BufferedFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	w := &BufferedFileWriter{
+		Path:          path,
+		FlushInterval: time.Hour,
+	}
+
+	for i := 0; i < 100; i++ {
+		w.Write(time.Now(), logger.Info, "Request", benchFields)
+	}
+
+	assert.NilError(t, w.Flush(context.Background()))
+	content, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, 100, bytes.Count(content, []byte("\n")))
+
+	for i := 0; i < 10; i++ {
+		w.Write(time.Now(), logger.Info, "Request", benchFields)
+	}
+	assert.NilError(t, w.Close(context.Background()))
+	assert.NilError(t, w.Close(context.Background()))
+
+	content, err = ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, 110, bytes.Count(content, []byte("\n")))
+
+	// writes after close are dropped
+	w.Write(time.Now(), logger.Info, "Request", benchFields)
+	assert.Equal(t, uint64(1), w.Dropped())
+}
+
+func BenchmarkFileWriter(b *testing.B) {
+	w := &FileWriter{
+		Path:         filepath.Join(b.TempDir(), "access.log"),
+		ErrorAdapter: logger.DefaultAdapter(),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Write(time.Now(), logger.Info, "Request", benchFields)
+	}
+}
+
+func BenchmarkBufferedFileWriter(b *testing.B) {
+	w := &BufferedFileWriter{
+		Path:         filepath.Join(b.TempDir(), "access.log"),
+		ErrorAdapter: logger.DefaultAdapter(),
+		QueueSize:    b.N + 1,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Write(time.Now(), logger.Info, "Request", benchFields)
+	}
+
+	if err := w.Close(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,265 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Names of the supported access log formats.
+const (
+	// FormatJSON writes one JSON object per line with
+	// the time, severity, msg and fields keys. This is
+	// the default format.
+	FormatJSON = "json"
+
+	// FormatJSONFlat writes one JSON object per line with
+	// all fields on the top-level next to time, severity
+	// and msg.
+	FormatJSONFlat = "json-flat"
+
+	// FormatCommon writes access logs in the Apache Common
+	// Log Format.
+	FormatCommon = "common"
+
+	// FormatCombined writes access logs in the Apache
+	// Combined Log Format.
+	FormatCombined = "combined"
+
+	// FormatLogfmt writes key=value pairs.
+	FormatLogfmt = "logfmt"
+
+	// FormatTemplate writes access logs using a user
+	// provided text/template. See TemplateData.
+	FormatTemplate = "template"
+)
+
+// clfTimeFormat is the time format used by the common and
+// combined log formats.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// Formatter encodes a log message into a single line for the
+// access log file. The returned line must end in a new-line.
+type Formatter interface {
+	Format(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte
+}
+
+// FormatterFunc is a convenience type for implementing Formatter.
+type FormatterFunc func(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte
+
+// Format implements Formatter.
+func (fn FormatterFunc) Format(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+	return fn(clock, severity, msg, fields)
+}
+
+// NewFormatter returns the formatter for the format name. tmpl is
+// only used for FormatTemplate. An empty name selects FormatJSON.
+func NewFormatter(name string, tmpl string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "", FormatJSON:
+		return FormatterFunc(encodeEntry), nil
+	case FormatJSONFlat:
+		return FormatterFunc(formatJSONFlat), nil
+	case FormatCommon:
+		return FormatterFunc(formatCommon), nil
+	case FormatCombined:
+		return FormatterFunc(formatCombined), nil
+	case FormatLogfmt:
+		return FormatterFunc(formatLogfmt), nil
+	case FormatTemplate:
+		return NewTemplateFormatter(tmpl)
+	default:
+		return nil, fmt.Errorf("unsupported access log format %q", name)
+	}
+}
+
+func formatJSONFlat(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+	obj := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		obj[k] = v
+	}
+	obj["time"] = clock
+	obj["severity"] = severity
+	obj["msg"] = msg
+
+	blob, err := json.Marshal(obj)
+	if err != nil {
+		return encodeEntry(clock, severity, msg, logger.Fields{"error": err.Error()})
+	}
+
+	return append(blob, '\n')
+}
+
+func formatCommon(clock time.Time, _ logger.Severity, _ string, fields logger.Fields) []byte {
+	var buf bytes.Buffer
+	writeCommon(&buf, clock, fields)
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
+
+func formatCombined(clock time.Time, _ logger.Severity, _ string, fields logger.Fields) []byte {
+	referer := fieldString(fields, FieldReferer)
+	if referer == "-" {
+		// entries written before FieldReferer was added only
+		// contain the referer if it was logged as a request
+		// header.
+		referer = fieldString(fields, FieldRequestHeaderPrefix+"referer")
+	}
+
+	var buf bytes.Buffer
+	writeCommon(&buf, clock, fields)
+	fmt.Fprintf(&buf, " %s %s\n",
+		strconv.Quote(referer),
+		strconv.Quote(fieldString(fields, FieldUserAgent)),
+	)
+
+	return buf.Bytes()
+}
+
+// writeCommon writes an access log line in the Apache Common
+// Log Format to buf.
+func writeCommon(buf *bytes.Buffer, clock time.Time, fields logger.Fields) {
+	host := fieldString(fields, FieldRealIP)
+	if host == "-" {
+		host = fieldString(fields, FieldRemoteAddr)
+	}
+
+	proto := fieldString(fields, FieldProto)
+	if proto == "-" {
+		proto = "HTTP/1.1"
+	}
+
+	requestLine := fmt.Sprintf("%s %s %s",
+		fieldString(fields, FieldMethod),
+		fieldString(fields, FieldPath),
+		proto,
+	)
+
+	fmt.Fprintf(buf, "%s - - [%s] %s %s %s",
+		host,
+		clock.Format(clfTimeFormat),
+		strconv.Quote(requestLine),
+		fieldString(fields, FieldStatus),
+		fieldString(fields, FieldResponseSize),
+	)
+}
+
+func formatLogfmt(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+	var buf bytes.Buffer
+
+	writeLogfmtPair(&buf, "time", clock.Format(time.RFC3339Nano))
+	buf.WriteByte(' ')
+	writeLogfmtPair(&buf, "severity", severity.String())
+	buf.WriteByte(' ')
+	writeLogfmtPair(&buf, "msg", msg)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		writeLogfmtPair(&buf, k, fmt.Sprint(fields[k]))
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteByte('=')
+
+	if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+
+	buf.WriteString(value)
+}
+
+// fieldString returns the string representation of the field
+// key or "-" if the field is not set or empty.
+func fieldString(fields logger.Fields, key string) string {
+	val, ok := fields[key]
+	if !ok || val == nil {
+		return "-"
+	}
+
+	s := fmt.Sprint(val)
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// TemplateData is passed to access log templates.
+type TemplateData struct {
+	// Time is the time the log message has been created.
+	Time time.Time
+
+	// Severity is the severity of the log message.
+	Severity logger.Severity
+
+	// Msg is the log message.
+	Msg string
+
+	// Fields holds all fields of the log message.
+	Fields logger.Fields
+}
+
+// Field returns the string value of the field key or "-" if the
+// field is not set. It's meant to be used in templates like
+// {{ .Field FieldStatus }}.
+func (td TemplateData) Field(key string) string {
+	return fieldString(td.Fields, key)
+}
+
+// CLFTime returns Time formatted as used by the common log format.
+func (td TemplateData) CLFTime() string {
+	return td.Time.Format(clfTimeFormat)
+}
+
+// NewTemplateFormatter returns a formatter that renders log messages
+// using the text/template tmpl. The template is executed with
+// TemplateData. A new-line is appended if tmpl does not end with one.
+func NewTemplateFormatter(tmpl string) (Formatter, error) {
+	if tmpl == "" {
+		return nil, fmt.Errorf("empty access log template")
+	}
+
+	t, err := template.New("accesslog").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access log template: %w", err)
+	}
+
+	return FormatterFunc(func(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+		var buf bytes.Buffer
+		err := t.Execute(&buf, TemplateData{
+			Time:     clock,
+			Severity: severity,
+			Msg:      msg,
+			Fields:   fields,
+		})
+		if err != nil {
+			return encodeEntry(clock, severity, msg, logger.Fields{"error": err.Error()})
+		}
+
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+
+		return buf.Bytes()
+	}), nil
+}
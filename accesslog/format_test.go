@@ -0,0 +1,67 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func Test_Formatter(t *testing.T) {
+	clock := time.Date(2021, 12, 24, 18, 30, 0, 0, time.FixedZone("CET", 3600))
+	fields := logger.Fields{
+		"http:status":                 200,
+		"http:method":                 "GET",
+		"http:path":                   "/api/patients?q=max mustermann",
+		"http:proto":                  "HTTP/2.0",
+		"http:real-ip":                net.ParseIP("10.1.1.1"),
+		"http:user-agent":             "curl/7.0",
+		"http:request-header:referer": "https://example.com/",
+	}
+
+	cases := []struct {
+		Format   string
+		Template string
+		Expected string
+	}{
+		{
+			FormatCommon, "",
+			`10.1.1.1 - - [24/Dec/2021:18:30:00 +0100] "GET /api/patients?q=max mustermann HTTP/2.0" 200 -` + "\n",
+		},
+		{
+			FormatCombined, "",
+			`10.1.1.1 - - [24/Dec/2021:18:30:00 +0100] "GET /api/patients?q=max mustermann HTTP/2.0" 200 - "https://example.com/" "curl/7.0"` + "\n",
+		},
+		{
+			FormatLogfmt, "",
+			`time=2021-12-24T18:30:00+01:00 severity=info(5) msg=Request http:method=GET http:path="/api/patients?q=max mustermann" http:proto=HTTP/2.0 http:real-ip=10.1.1.1 http:request-header:referer=https://example.com/ http:status=200 http:user-agent=curl/7.0` + "\n",
+		},
+		{
+			FormatTemplate, `{{ .CLFTime }} {{ .Field "http:status" }} {{ .Field "http:latency" }}`,
+			"24/Dec/2021:18:30:00 +0100 200 -\n",
+		},
+	}
+
+	for _, c := range cases {
+		f, err := NewFormatter(c.Format, c.Template)
+		assert.NilError(t, err)
+		assert.Equal(t, c.Expected, string(f.Format(clock, logger.Info, "Request", fields)), c.Format)
+	}
+
+	f, err := NewFormatter(FormatJSONFlat, "")
+	assert.NilError(t, err)
+
+	var obj map[string]interface{}
+	assert.NilError(t, json.Unmarshal(f.Format(clock, logger.Info, "Request", fields), &obj))
+	assert.Equal(t, "Request", obj["msg"])
+	assert.Equal(t, float64(200), obj["http:status"])
+
+	_, err = NewFormatter("xml", "")
+	assert.Assert(t, err != nil)
+
+	_, err = NewFormatter(FormatTemplate, "{{ .Field ")
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,129 @@
+package accesslog
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the values of redacted query parameters,
+// headers and fields.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedQuery holds query parameters that are always
+// redacted by the request logger returned by New.
+var DefaultRedactedQuery = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"password",
+	"secret",
+	"token",
+}
+
+// DefaultRedactedHeaders holds headers that are always redacted
+// if they are logged using WithRequestHeaders or WithResponseHeaders.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+}
+
+// Option configures the request logger returned by New.
+type Option func(rl *requestLogger)
+
+// WithRedactedQuery redacts the values of the query parameters
+// params. Parameter names are matched case-insensitive.
+func WithRedactedQuery(params ...string) Option {
+	return func(rl *requestLogger) {
+		addLower(rl.redactQuery, params)
+	}
+}
+
+// WithRedactedHeaders redacts the values of headers if they are
+// logged.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(rl *requestLogger) {
+		addLower(rl.redactHeaders, headers)
+	}
+}
+
+// WithRedactedFields redacts the values of fields added to the
+// request context or to the gin context during request handling.
+func WithRedactedFields(fields ...string) Option {
+	return func(rl *requestLogger) {
+		for _, f := range fields {
+			rl.redactFields[f] = struct{}{}
+		}
+	}
+}
+
+// WithRequestHeaders adds the values of the request headers to
+// the log fields. Fields are named http:request-header:<name>
+// with name in lower-case.
+func WithRequestHeaders(headers ...string) Option {
+	return func(rl *requestLogger) {
+		rl.requestHeaders = append(rl.requestHeaders, headers...)
+	}
+}
+
+// WithResponseHeaders adds the values of the response headers to
+// the log fields. Fields are named http:response-header:<name>
+// with name in lower-case.
+func WithResponseHeaders(headers ...string) Option {
+	return func(rl *requestLogger) {
+		rl.responseHeaders = append(rl.responseHeaders, headers...)
+	}
+}
+
+// WithListenerName configures a function that returns the name
+// of the listener that accepted r. The name is logged as
+// FieldListener.
+func WithListenerName(fn func(r *http.Request) string) Option {
+	return func(rl *requestLogger) {
+		rl.listenerName = fn
+	}
+}
+
+func addLower(m map[string]struct{}, values []string) {
+	for _, v := range values {
+		m[strings.ToLower(v)] = struct{}{}
+	}
+}
+
+// WithRules configures rules that decide whether or not a request
+// is logged. The first matching rule wins and requests that do not
+// match any rule are logged. Requests that failed with a 5xx status
+// code or reported errors to the gin context, as well as slow requests
+// (see WithSlowThreshold) are always logged.
+func WithRules(rules ...*Rule) Option {
+	return func(rl *requestLogger) {
+		rl.rules = append(rl.rules, rules...)
+	}
+}
+
+// WithSlowThreshold configures the latency threshold above which
+// requests are always logged, even if skipped or sampled by rules.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(rl *requestLogger) {
+		rl.slowThreshold = d
+	}
+}
+
+// WithSlowRequests configures a slow request detector that observes
+// the latency of each request.
+func WithSlowRequests(s *SlowRequests) Option {
+	if s == nil {
+		return func(*requestLogger) {}
+	}
+	return WithObserver(s)
+}
+
+// WithObserver adds an observer that is called for each request,
+// whether or not it is logged.
+func WithObserver(o Observer) Option {
+	return func(rl *requestLogger) {
+		rl.observers = append(rl.observers, o)
+	}
+}
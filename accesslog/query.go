@@ -0,0 +1,340 @@
+package accesslog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tierklinik-dobersberg/service/utils"
+)
+
+// Entry is a single access log entry read from an access log file.
+type Entry struct {
+	Time     time.Time
+	Severity int
+	Msg      string
+	Fields   map[string]interface{}
+}
+
+// UnmarshalJSON supports entries written using FormatJSON and
+// FormatJSONFlat.
+func (e *Entry) UnmarshalJSON(blob []byte) error {
+	var obj map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return err
+	}
+
+	if t, ok := obj["time"].(string); ok {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return fmt.Errorf("invalid time: %w", err)
+		}
+		e.Time = parsed
+	}
+	if s, ok := obj["severity"].(json.Number); ok {
+		sev, _ := s.Int64()
+		e.Severity = int(sev)
+	}
+	e.Msg, _ = obj["msg"].(string)
+
+	if fields, ok := obj["fields"].(map[string]interface{}); ok {
+		e.Fields = fields
+		return nil
+	}
+
+	// flat JSON format
+	delete(obj, "time")
+	delete(obj, "severity")
+	delete(obj, "msg")
+	e.Fields = obj
+
+	return nil
+}
+
+// String returns the value of the field key as a string.
+func (e *Entry) String(key string) string {
+	switch v := e.Fields[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Int returns the value of the field key as an integer.
+func (e *Entry) Int(key string) int64 {
+	switch v := e.Fields[key].(type) {
+	case json.Number:
+		i, _ := v.Int64()
+		return i
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// Status returns the HTTP status code of the request.
+func (e *Entry) Status() int {
+	return int(e.Int(FieldStatus))
+}
+
+// Latency returns the latency of the request.
+func (e *Entry) Latency() time.Duration {
+	return time.Duration(e.Int(FieldLatencyRaw))
+}
+
+// Path returns the request path without query parameters.
+func (e *Entry) Path() string {
+	p := e.String(FieldPath)
+	if idx := strings.Index(p, "?"); idx >= 0 {
+		p = p[:idx]
+	}
+	return p
+}
+
+// Route returns the gin route template of the request. If the
+// entry does not have a route the request path is returned.
+func (e *Entry) Route() string {
+	if r := e.String(FieldRoute); r != "" {
+		return r
+	}
+	return e.Path()
+}
+
+// ClientIP returns the IP address of the client.
+func (e *Entry) ClientIP() string {
+	if ip := e.String(FieldRealIP); ip != "" {
+		return ip
+	}
+	return utils.RemovePort(e.String(FieldRemoteAddr))
+}
+
+// Filter selects access log entries. Zero values match all
+// entries.
+type Filter struct {
+	Since       time.Time
+	Until       time.Time
+	StatusClass int
+	Status      int
+	Path        string
+	Networks    utils.IPNetworks
+	MinLatency  time.Duration
+	MaxLatency  time.Duration
+}
+
+// Matches returns true if e matches all criteria of f.
+func (f *Filter) Matches(e *Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Status != 0 && e.Status() != f.Status {
+		return false
+	}
+	if f.StatusClass != 0 && e.Status()/100 != f.StatusClass {
+		return false
+	}
+	if f.Path != "" && !matchPath(f.Path, e.Path()) && f.Path != e.Route() {
+		return false
+	}
+	if len(f.Networks) > 0 && !f.Networks.ContainsString(e.ClientIP()) {
+		return false
+	}
+	if f.MinLatency > 0 && e.Latency() < f.MinLatency {
+		return false
+	}
+	if f.MaxLatency > 0 && e.Latency() > f.MaxLatency {
+		return false
+	}
+
+	return true
+}
+
+// LogFiles returns the access log at path and all rotated access
+// log files, oldest first.
+func LogFiles(path string) ([]string, error) {
+	lf := &logFile{path: path}
+	backups, err := lf.backups()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(backups)+1)
+	for idx := len(backups) - 1; idx >= 0; idx-- {
+		files = append(files, backups[idx].path)
+	}
+
+	if fileExists(path) {
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// ReadFile reads all access log entries from the file at path and
+// calls fn for each of them. Gzip compressed files are detected and
+// decompressed automatically. Lines that cannot be parsed are
+// skipped.
+func ReadFile(path string, fn func(*Entry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+
+	// check for the gzip magic bytes.
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return Read(r, fn)
+}
+
+// Read reads all access log entries from r and calls fn for each
+// of them. Lines that cannot be parsed are skipped.
+func Read(r io.Reader, fn func(*Entry) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Stats aggregates access log entries.
+type Stats struct {
+	Total     int
+	Routes    map[string]int
+	Statuses  map[int]int
+	ClientIPs map[string]int
+	latencies []time.Duration
+}
+
+// NewStats returns a new, empty Stats.
+func NewStats() *Stats {
+	return &Stats{
+		Routes:    make(map[string]int),
+		Statuses:  make(map[int]int),
+		ClientIPs: make(map[string]int),
+	}
+}
+
+// Add adds e to the statistics.
+func (s *Stats) Add(e *Entry) {
+	s.Total++
+	s.Routes[e.String(FieldMethod)+" "+e.Route()]++
+	s.Statuses[e.Status()]++
+	s.ClientIPs[e.ClientIP()]++
+	s.latencies = append(s.latencies, e.Latency())
+}
+
+// Percentile returns the p-th percentile (0-100) of all request
+// latencies using the nearest-rank method.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool {
+		return s.latencies[i] < s.latencies[j]
+	})
+
+	rank := int(p/100*float64(len(s.latencies))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(s.latencies) {
+		rank = len(s.latencies) - 1
+	}
+
+	return s.latencies[rank]
+}
+
+// Count is a key with the number of it's occurrences.
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Top returns the n keys of m with the highest count. If n is
+// zero or negative all keys are returned.
+func Top(m map[string]int, n int) []Count {
+	result := make([]Count, 0, len(m))
+	for k, v := range m {
+		result = append(result, Count{k, v})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+
+	return result
+}
+
+// Report is a summary of access log statistics.
+type Report struct {
+	Total     int         `json:"total"`
+	Routes    []Count     `json:"routes"`
+	Statuses  map[int]int `json:"statuses"`
+	P50       string      `json:"p50"`
+	P95       string      `json:"p95"`
+	P99       string      `json:"p99"`
+	ClientIPs []Count     `json:"clientIPs"`
+}
+
+// Report returns a summary of s including the top n routes and
+// client IPs.
+func (s *Stats) Report(n int) Report {
+	return Report{
+		Total:     s.Total,
+		Routes:    Top(s.Routes, n),
+		Statuses:  s.Statuses,
+		P50:       s.Percentile(50).String(),
+		P95:       s.Percentile(95).String(),
+		P99:       s.Percentile(99).String(),
+		ClientIPs: Top(s.ClientIPs, n),
+	}
+}
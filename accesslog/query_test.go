@@ -0,0 +1,78 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func Test_QueryCommand(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	now := time.Now()
+
+	entry := func(format Formatter, age time.Duration, status int, route, ip string, latency time.Duration) []byte {
+		return format.Format(now.Add(-age), logger.Info, "Request", logger.Fields{
+			"http:status":      status,
+			"http:method":      "GET",
+			"http:path":        strings.Replace(route, ":id", "1", 1) + "?q=1",
+			"http:route":       route,
+			"http:real-ip":     net.ParseIP(ip),
+			"http:latency-raw": latency,
+		})
+	}
+
+	flat, err := NewFormatter(FormatJSONFlat, "")
+	assert.NilError(t, err)
+	envelope, err := NewFormatter(FormatJSON, "")
+	assert.NilError(t, err)
+
+	// a rotated and compressed file
+	var old bytes.Buffer
+	old.Write(entry(envelope, 48*time.Hour, 200, "/patients/:id", "10.0.0.1", time.Millisecond))
+	old.Write(entry(envelope, 47*time.Hour, 404, "/patients/:id", "10.0.0.2", 2*time.Millisecond))
+	backup := filepath.Join(dir, "access-"+now.Add(-time.Hour).Format(backupTimeFormat)+".log")
+	assert.NilError(t, ioutil.WriteFile(backup, old.Bytes(), 0644))
+	assert.NilError(t, compressFile(backup))
+
+	var current bytes.Buffer
+	current.Write(entry(flat, time.Minute, 200, "/patients/:id", "10.0.0.1", 3*time.Millisecond))
+	current.Write(entry(envelope, time.Minute, 500, "/visits", "192.168.0.1", 100*time.Millisecond))
+	current.WriteString("not json\n")
+	assert.NilError(t, ioutil.WriteFile(path, current.Bytes(), 0644))
+
+	run := func(args ...string) Report {
+		var out bytes.Buffer
+		assert.NilError(t, QueryCommand(append(append(args, "-json"), path), &out))
+
+		var report Report
+		assert.NilError(t, json.Unmarshal(out.Bytes(), &report))
+		return report
+	}
+
+	report := run()
+	assert.Equal(t, 4, report.Total)
+	assert.DeepEqual(t, []Count{{"GET /patients/:id", 3}, {"GET /visits", 1}}, report.Routes)
+	assert.DeepEqual(t, map[int]int{200: 2, 404: 1, 500: 1}, report.Statuses)
+	assert.Equal(t, "2ms", report.P50)
+	assert.Equal(t, "100ms", report.P99)
+	assert.DeepEqual(t, Count{"10.0.0.1", 2}, report.ClientIPs[0])
+
+	assert.Equal(t, 2, run("-since", "24h").Total)
+	assert.Equal(t, 2, run("-until", "24h").Total)
+	assert.Equal(t, 1, run("-status", "5xx").Total)
+	assert.Equal(t, 1, run("-status", "404").Total)
+	assert.Equal(t, 3, run("-ip", "10.0.0.0/8").Total)
+	assert.Equal(t, 1, run("-path", "/visits").Total)
+	assert.Equal(t, 3, run("-path", "/patients/*").Total)
+	assert.Equal(t, 2, run("-min-latency", "3ms").Total)
+	assert.Equal(t, 2, run("-rotated=false").Total)
+}
@@ -1,6 +1,8 @@
 package accesslog
 
 import (
+	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -9,53 +11,235 @@ import (
 	"github.com/tierklinik-dobersberg/service/utils"
 )
 
+// Observer observes all requests handled by the request logger
+// returned by New.
+type Observer interface {
+	// Observe is called after the request in c has been handled.
+	// fields holds all fields added to the request context or the
+	// gin.Context during request handling and must not be
+	// modified.
+	Observe(c *gin.Context, latency time.Duration, fields logger.Fields)
+}
+
+type requestLogger struct {
+	log             logger.Logger
+	redactQuery     map[string]struct{}
+	redactHeaders   map[string]struct{}
+	redactFields    map[string]struct{}
+	requestHeaders  []string
+	responseHeaders []string
+	rules           []*Rule
+	slowThreshold   time.Duration
+	observers       []Observer
+	listenerName    func(r *http.Request) string
+}
+
 // New returns a gin handler function that logs all incoming
-// requests to log.‚
-func New(log logger.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
-		if raw != "" {
-			path = path + "?" + raw
-		}
+// requests to log. The values of query parameters in
+// DefaultRedactedQuery are always redacted.
+func New(log logger.Logger, opts ...Option) gin.HandlerFunc {
+	rl := &requestLogger{
+		log:           log,
+		redactQuery:   make(map[string]struct{}),
+		redactHeaders: make(map[string]struct{}),
+		redactFields:  make(map[string]struct{}),
+	}
+	addLower(rl.redactQuery, DefaultRedactedQuery)
+	addLower(rl.redactHeaders, DefaultRedactedHeaders)
+
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	return rl.handle
+}
+
+func (rl *requestLogger) handle(c *gin.Context) {
+	start := time.Now()
+	path := c.Request.URL.Path
+	raw := rl.redactRawQuery(c.Request.URL.RawQuery)
+	if raw != "" {
+		path = path + "?" + raw
+	}
+
+	c.Next()
+
+	end := time.Now()
+	latency := end.Sub(start)
+
+	// fields added to the request context or the gin.Context
+	// during request handling.
+	handlerFields := rl.handlerFields(c)
+
+	for _, o := range rl.observers {
+		o.Observe(c, latency, handlerFields)
+	}
 
-		c.Next()
+	if !rl.shouldLog(c, latency) {
+		return
+	}
 
-		end := time.Now()
-		latency := end.Sub(start)
+	msg := "Request"
+	if len(c.Errors) > 0 {
+		msg = c.Errors.String()
+	}
 
-		msg := "Request"
-		if len(c.Errors) > 0 {
-			msg = c.Errors.String()
+	size := c.Writer.Size()
+	if size < 0 {
+		size = 0
+	}
+
+	fields := logger.Fields{
+		FieldStatus:       c.Writer.Status(),
+		FieldMethod:       c.Request.Method,
+		FieldProto:        c.Request.Proto,
+		FieldPath:         path,
+		FieldRoute:        c.FullPath(),
+		FieldHandler:      c.HandlerName(),
+		FieldRemoteAddr:   c.Request.RemoteAddr,
+		FieldRealIP:       utils.RealClientIP(c.Request),
+		FieldLatency:      latency.String(),
+		FieldLatencyRaw:   latency,
+		FieldUserAgent:    c.Request.UserAgent(),
+		FieldResponseSize: size,
+	}
+
+	// the referer is always logged as it's part of the
+	// combined log format.
+	if referer := c.Request.Referer(); referer != "" {
+		if _, ok := rl.redactHeaders["referer"]; ok {
+			referer = RedactedValue
 		}
+		fields[FieldReferer] = rl.redactReferer(referer)
+	}
 
-		fields := logger.Fields{
-			"http:status":      c.Writer.Status(),
-			"http:method":      c.Request.Method,
-			"http:path":        path,
-			"http:remote-addr": c.Request.RemoteAddr,
-			"http:real-ip":     utils.RealClientIP(c.Request),
-			"http:latency":     latency.String(),
-			"http:latency-raw": latency,
-			"http:user-agent":  c.Request.UserAgent(),
+	if rl.listenerName != nil {
+		if name := rl.listenerName(c.Request); name != "" {
+			fields[FieldListener] = name
 		}
+	}
+
+	rl.headerFields(fields, FieldRequestHeaderPrefix, c.Request.Header, rl.requestHeaders)
+	rl.headerFields(fields, FieldResponseHeaderPrefix, c.Writer.Header(), rl.responseHeaders)
 
-		// merge existing fields in the request context
-		existingFields := logger.ContextFields(c.Request.Context())
-		for k, v := range existingFields {
+	for k, v := range handlerFields {
+		fields[k] = v
+	}
+
+	rl.log.WithFields(fields).Info(msg)
+}
+
+// handlerFields returns all fields of the request context and all
+// public keys of the gin.Context with redaction rules applied.
+func (rl *requestLogger) handlerFields(c *gin.Context) logger.Fields {
+	fields := make(logger.Fields)
+
+	// merge existing fields in the request context
+	existingFields := logger.ContextFields(c.Request.Context())
+	for k, v := range existingFields {
+		fields[k] = v
+	}
+
+	// merge fields from the gin.Context
+	for k, v := range c.Keys {
+		// gin keys prefixed with underscore are marked
+		// as private.
+		if !strings.HasPrefix(k, "_") {
 			fields[k] = v
 		}
+	}
+
+	for k := range rl.redactFields {
+		if _, ok := fields[k]; ok {
+			fields[k] = RedactedValue
+		}
+	}
+
+	return fields
+}
+
+// shouldLog evaluates all rules to decide whether or not the request
+// in c should be logged.
+func (rl *requestLogger) shouldLog(c *gin.Context, latency time.Duration) bool {
+	status := c.Writer.Status()
+
+	// always keep errors and slow requests
+	if status >= 500 || len(c.Errors) > 0 {
+		return true
+	}
+	if rl.slowThreshold > 0 && latency >= rl.slowThreshold {
+		return true
+	}
+
+	for _, r := range rl.rules {
+		if r.Matches(c.Request.Method, c.Request.URL.Path, status, latency) {
+			return r.shouldLog()
+		}
+	}
+
+	return true
+}
+
+// redactReferer redacts the query string of the referer URL
+// like the query string of the request path.
+func (rl *requestLogger) redactReferer(referer string) string {
+	idx := strings.IndexByte(referer, '?')
+	if idx < 0 {
+		return referer
+	}
+
+	query, fragment := referer[idx+1:], ""
+	if end := strings.IndexByte(query, '#'); end >= 0 {
+		query, fragment = query[:end], query[end:]
+	}
+
+	return referer[:idx+1] + rl.redactRawQuery(query) + fragment
+}
+
+// redactRawQuery replaces the values of all redacted parameters in
+// the raw query string. The order of parameters is kept.
+func (rl *requestLogger) redactRawQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+
+	parts := strings.Split(raw, "&")
+	for idx, p := range parts {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		key := kv[0]
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if _, ok := rl.redactQuery[strings.ToLower(key)]; ok {
+			parts[idx] = kv[0] + "=" + RedactedValue
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// headerFields adds the values of all headers in names to fields
+// using prefix.
+func (rl *requestLogger) headerFields(fields map[string]interface{}, prefix string, h http.Header, names []string) {
+	for _, name := range names {
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
 
-		// merge fields from the gin.Context
-		for k, v := range c.Keys {
-			// gin keys prefixed with underscore are marked
-			// as private.
-			if !strings.HasPrefix(k, "_") {
-				fields[k] = v
-			}
+		lower := strings.ToLower(name)
+		value := strings.Join(values, ", ")
+		if _, ok := rl.redactHeaders[lower]; ok {
+			value = RedactedValue
+		} else if lower == "referer" {
+			value = rl.redactReferer(value)
 		}
 
-		log.WithFields(fields).Info(msg)
+		fields[prefix+lower] = value
 	}
 }
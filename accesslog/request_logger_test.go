@@ -0,0 +1,119 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// recordRequest sends req to a gin engine that uses the request
+// logger and returns the fields of the logged message.
+func recordRequest(t *testing.T, req *http.Request, handler gin.HandlerFunc, opts ...Option) logger.Fields {
+	var fields logger.Fields
+	log := logger.New(logger.AdapterFunc(func(_ time.Time, _ logger.Severity, _ string, f logger.Fields) {
+		fields = f
+	}))
+
+	engine := gin.New()
+	engine.Use(New(log, opts...))
+	engine.GET("/test", handler)
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	return fields
+}
+
+func Test_RequestLoggerRedaction(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?name=max&access_token=abc&SessionID=123&flag", nil)
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("Authorization", "Bearer abc")
+
+	fields := recordRequest(t, req, func(c *gin.Context) {
+		c.Set("user", "max")
+		c.Set("session", "secret-session")
+		c.Header("X-Request-ID", "req-1")
+		c.String(http.StatusOK, "hello")
+	},
+		WithRedactedQuery("sessionid"),
+		WithRedactedFields("session"),
+		WithRequestHeaders("Referer", "Authorization", "X-Missing"),
+		WithResponseHeaders("X-Request-ID"),
+	)
+
+	assert.Equal(t, "/test?name=max&access_token=[REDACTED]&SessionID=[REDACTED]&flag", fields["http:path"])
+	assert.Equal(t, "https://example.com/", fields["http:request-header:referer"])
+	assert.Equal(t, RedactedValue, fields["http:request-header:authorization"])
+	assert.Equal(t, "req-1", fields["http:response-header:x-request-id"])
+	assert.Equal(t, 5, fields["http:response-size"])
+	assert.Equal(t, "max", fields["user"])
+	assert.Equal(t, RedactedValue, fields["session"])
+
+	_, ok := fields["http:request-header:x-missing"]
+	assert.Assert(t, !ok)
+}
+
+func Test_RequestLoggerCombinedReferer(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Referer", "https://example.com/patients")
+	req.Header.Set("User-Agent", "curl/7.0")
+
+	// the referer must be logged without WithRequestHeaders.
+	fields := recordRequest(t, req, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	assert.Equal(t, "https://example.com/patients", fields[FieldReferer])
+
+	f, err := NewFormatter(FormatCombined, "")
+	assert.NilError(t, err)
+
+	line := string(f.Format(time.Now(), logger.Info, "Request", fields))
+	assert.Assert(t, strings.HasSuffix(line, `204 0 "https://example.com/patients" "curl/7.0"`+"\n"), line)
+}
+
+func Test_RequestLoggerRefererRedaction(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Referer", "https://app/cb?token=abc&page=2&sid=1#top")
+
+	fields := recordRequest(t, req, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	},
+		WithRedactedQuery("sid"),
+		WithRequestHeaders("Referer"),
+	)
+
+	expected := "https://app/cb?token=[REDACTED]&page=2&sid=[REDACTED]#top"
+	assert.Equal(t, expected, fields[FieldReferer])
+	assert.Equal(t, expected, fields[FieldRequestHeaderPrefix+"referer"])
+}
+
+func countingLogger(count *int) logger.Logger {
+	return logger.New(logger.AdapterFunc(func(time.Time, logger.Severity, string, logger.Fields) {
+		*count++
+	}))
+}
+
+func Test_RequestLoggerRouteFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?id=1", nil)
+
+	fields := recordRequest(t, req, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	}, WithListenerName(func(r *http.Request) string {
+		return "public"
+	}))
+
+	assert.Equal(t, "/test?id=1", fields[FieldPath])
+	assert.Equal(t, "/test", fields[FieldRoute])
+	assert.Equal(t, "public", fields[FieldListener])
+	assert.Equal(t, "HTTP/1.1", fields[FieldProto])
+	assert.Assert(t, strings.Contains(fields[FieldHandler].(string), "Test_RequestLoggerRouteFields"))
+}
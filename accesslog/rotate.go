@@ -0,0 +1,331 @@
+package accesslog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// backupTimeFormat is the time format used in the name of
+// rotated log files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotationPolicy configures when log files are rotated and how
+// rotated files are handled. The zero value disables rotation.
+type RotationPolicy struct {
+	// MaxSize is the maximum size of the log file in bytes
+	// before it is rotated. Zero disables size based rotation.
+	MaxSize int64
+
+	// Daily enables rotation of the log file each day.
+	Daily bool
+
+	// MaxAge is the maximum age of rotated log files. Older
+	// files are removed. Zero keeps rotated files forever.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of rotated log files
+	// to keep. Zero keeps all rotated files.
+	MaxBackups int
+
+	// Compress enables gzip compression of rotated log files.
+	Compress bool
+}
+
+// Enabled returns true if p requires log files to be rotated.
+func (p RotationPolicy) Enabled() bool {
+	return p.MaxSize > 0 || p.Daily
+}
+
+// logFile is an append-only log file that is rotated according
+// to a RotationPolicy. logFile is not safe for concurrent use.
+type logFile struct {
+	path         string
+	policy       RotationPolicy
+	errorAdapter logger.Adapter
+
+	f        *os.File
+	size     int64
+	openedAt time.Time
+
+	// millLock serializes compressing and pruning rotated files.
+	millLock sync.Mutex
+	milling  sync.WaitGroup
+}
+
+// Write writes p to the log file and rotates the file before if
+// required. Callers should only pass complete log lines to Write
+// so they are never split between files.
+func (lf *logFile) Write(p []byte) (int, error) {
+	if lf.f == nil {
+		if err := lf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if lf.shouldRotate(len(p)) {
+		if err := lf.rotate(); err != nil {
+			// keep writing to the current file so we don't lose
+			// log messages.
+			lf.reportError("failed to rotate log file", err)
+		}
+	}
+
+	n, err := lf.f.Write(p)
+	lf.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the log file. The file is re-opened on the next
+// call to Write. Close is used to support external log rotation.
+func (lf *logFile) Close() error {
+	if lf.f == nil {
+		return nil
+	}
+
+	err := lf.f.Close()
+	lf.f = nil
+
+	return err
+}
+
+func (lf *logFile) open() error {
+	f, err := os.OpenFile(lf.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	lf.f = f
+	lf.size = 0
+	lf.openedAt = time.Now()
+
+	// if we append to an existing file use it's size and
+	// modification time instead.
+	if stat, err := f.Stat(); err == nil && stat.Size() > 0 {
+		lf.size = stat.Size()
+		lf.openedAt = stat.ModTime()
+	}
+
+	return nil
+}
+
+func (lf *logFile) shouldRotate(n int) bool {
+	if lf.policy.MaxSize > 0 && lf.size > 0 && lf.size+int64(n) > lf.policy.MaxSize {
+		return true
+	}
+
+	if lf.policy.Daily {
+		y1, m1, d1 := lf.openedAt.Date()
+		y2, m2, d2 := time.Now().Date()
+
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+
+	return false
+}
+
+func (lf *logFile) rotate() error {
+	if err := lf.Close(); err != nil {
+		return err
+	}
+
+	// make sure we never overwrite an existing backup if we
+	// rotate more than once per millisecond.
+	t := time.Now()
+	backup := lf.backupName(t)
+	for fileExists(backup) || fileExists(backup+".gz") {
+		t = t.Add(time.Millisecond)
+		backup = lf.backupName(t)
+	}
+
+	renameErr := os.Rename(lf.path, backup)
+
+	// always re-open the log file so we can continue writing.
+	if err := lf.open(); err != nil {
+		return err
+	}
+
+	if renameErr != nil {
+		return renameErr
+	}
+
+	lf.milling.Add(1)
+	go lf.mill(backup)
+
+	return nil
+}
+
+// backupName returns the name of a rotated log file. The timestamp
+// is placed between the file name and it's extension so
+// access.log becomes access-2006-01-02T15-04-05.000.log.
+func (lf *logFile) backupName(t time.Time) string {
+	dir, prefix, ext := lf.nameParts()
+
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+}
+
+func (lf *logFile) nameParts() (dir, prefix, ext string) {
+	dir, name := filepath.Split(lf.path)
+	ext = filepath.Ext(name)
+
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// mill compresses the rotated file at path, if enabled, and removes
+// old rotated files according to the policy.
+func (lf *logFile) mill(path string) {
+	defer lf.milling.Done()
+
+	lf.millLock.Lock()
+	defer lf.millLock.Unlock()
+
+	// the rotated file may already have been pruned by a previous
+	// run if we rotate faster than we compress.
+	if lf.policy.Compress && fileExists(path) {
+		if err := compressFile(path); err != nil {
+			lf.reportError("failed to compress rotated log file", err)
+		}
+	}
+
+	if err := lf.prune(); err != nil {
+		lf.reportError("failed to remove old log files", err)
+	}
+}
+
+// backupFile describes a rotated log file.
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backups returns all rotated log files sorted by their rotation
+// time, newest first.
+func (lf *logFile) backups() ([]backupFile, error) {
+	dir, prefix, ext := lf.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		ts = strings.TrimSuffix(ts, ext)
+
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, backupFile{
+			path: filepath.Join(dir, name),
+			time: t,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].time.After(result[j].time)
+	})
+
+	return result, nil
+}
+
+func (lf *logFile) prune() error {
+	if lf.policy.MaxAge <= 0 && lf.policy.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := lf.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-lf.policy.MaxAge)
+	for idx, b := range backups {
+		tooMany := lf.policy.MaxBackups > 0 && idx >= lf.policy.MaxBackups
+		tooOld := lf.policy.MaxAge > 0 && b.time.Before(cutoff)
+
+		if tooMany || tooOld {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (lf *logFile) reportError(msg string, err error) {
+	adapter := lf.errorAdapter
+	if adapter == nil {
+		adapter = logger.DefaultAdapter()
+	}
+
+	adapter.Write(time.Now(), logger.Error, msg, logger.Fields{
+		"error": err.Error(),
+		"path":  lf.path,
+	})
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compressFile compresses path using gzip and removes the
+// uncompressed file afterwards.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return fmt.Errorf("failed to close %s.gz: %w", path, err)
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,85 @@
+package accesslog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func Test_logFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	lf := &logFile{
+		path: filepath.Join(dir, "access.log"),
+		policy: RotationPolicy{
+			MaxSize:    20,
+			MaxBackups: 2,
+			Compress:   true,
+		},
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := lf.Write([]byte("0123456789012345\n"))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, lf.Close())
+	lf.milling.Wait()
+
+	backups, err := lf.backups()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(backups))
+
+	for _, b := range backups {
+		assert.Assert(t, strings.HasSuffix(b.path, ".log.gz"), b.path)
+
+		f, err := os.Open(b.path)
+		assert.NilError(t, err)
+		gz, err := gzip.NewReader(f)
+		assert.NilError(t, err)
+		content, err := ioutil.ReadAll(gz)
+		assert.NilError(t, err)
+		f.Close()
+
+		assert.Equal(t, "0123456789012345\n", string(content))
+	}
+
+	content, err := ioutil.ReadFile(lf.path)
+	assert.NilError(t, err)
+	assert.Equal(t, "0123456789012345\n", string(content))
+}
+
+func Test_logFileDailyRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+
+	assert.NilError(t, ioutil.WriteFile(path, []byte("yesterday\n"), 0644))
+	yesterday := time.Now().Add(-24 * time.Hour)
+	assert.NilError(t, os.Chtimes(path, yesterday, yesterday))
+
+	lf := &logFile{
+		path:   path,
+		policy: RotationPolicy{Daily: true},
+	}
+
+	_, err := lf.Write([]byte("today\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, lf.Close())
+	lf.milling.Wait()
+
+	backups, err := lf.backups()
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(backups))
+
+	content, err := ioutil.ReadFile(backups[0].path)
+	assert.NilError(t, err)
+	assert.Equal(t, "yesterday\n", string(content))
+
+	content, err = ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, "today\n", string(content))
+}
@@ -0,0 +1,191 @@
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Actions supported by access log rules.
+const (
+	// ActionLog always logs matching requests.
+	ActionLog = "log"
+
+	// ActionSkip never logs matching requests.
+	ActionSkip = "skip"
+
+	// ActionSample logs only one out of SampleRate
+	// matching requests.
+	ActionSample = "sample"
+)
+
+// httpMethods holds all HTTP methods supported in rules.
+var httpMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodPost:    {},
+	http.MethodPut:     {},
+	http.MethodPatch:   {},
+	http.MethodDelete:  {},
+	http.MethodConnect: {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+}
+
+// Rule decides whether or not a request should be logged. A rule
+// matches a request if all of it's criteria match. Empty criteria
+// match all requests.
+type Rule struct {
+	// Path is a path pattern as supported by path.Match. If Path
+	// ends in /** all paths starting with the literal prefix
+	// match as well.
+	Path string
+
+	// Methods holds the HTTP methods the rule applies to.
+	Methods []string
+
+	// StatusClass is the status class (like 2 for 2xx) the rule
+	// applies to.
+	StatusClass int
+
+	// MinLatency restricts the rule to requests that took at
+	// least MinLatency.
+	MinLatency time.Duration
+
+	// Action is the action to take for matching requests.
+	Action string
+
+	// SampleRate configures ActionSample to log one out of
+	// SampleRate requests.
+	SampleRate uint64
+
+	counter uint64
+}
+
+// ParseRule parses a rule from it's string representation. The first
+// token is the action (log, skip or sample:<rate>) followed by any
+// number of criteria. Criteria other than paths, status classes and
+// latencies must be comma separated lists of HTTP methods:
+//
+//	skip GET,HEAD /healthz
+//	sample:100 /static/**
+//	log status=4xx
+//	log latency>=500ms
+func ParseRule(s string) (*Rule, error) {
+	tokens := strings.Fields(s)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	r := new(Rule)
+
+	action := strings.ToLower(tokens[0])
+	switch {
+	case action == ActionLog, action == ActionSkip:
+		r.Action = action
+	case strings.HasPrefix(action, ActionSample+":"):
+		rate, err := strconv.ParseUint(strings.TrimPrefix(action, ActionSample+":"), 10, 64)
+		if err != nil || rate == 0 {
+			return nil, fmt.Errorf("rule %q: invalid sample rate", s)
+		}
+		r.Action = ActionSample
+		r.SampleRate = rate
+	default:
+		return nil, fmt.Errorf("rule %q: unknown action %q", s, tokens[0])
+	}
+
+	for _, tok := range tokens[1:] {
+		switch {
+		case strings.HasPrefix(tok, "/"):
+			if _, err := path.Match(strings.TrimSuffix(tok, "/**"), "/"); err != nil {
+				return nil, fmt.Errorf("rule %q: invalid path pattern: %w", s, err)
+			}
+			r.Path = tok
+
+		case strings.HasPrefix(tok, "status="):
+			class := strings.TrimPrefix(tok, "status=")
+			if len(class) != 3 || !strings.HasSuffix(class, "xx") || class[0] < '1' || class[0] > '5' {
+				return nil, fmt.Errorf("rule %q: invalid status class %q", s, class)
+			}
+			r.StatusClass = int(class[0] - '0')
+
+		case strings.HasPrefix(tok, "latency>="):
+			d, err := time.ParseDuration(strings.TrimPrefix(tok, "latency>="))
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid latency: %w", s, err)
+			}
+			r.MinLatency = d
+
+		default:
+			// anything else must be a list of HTTP methods.
+			for _, m := range strings.Split(tok, ",") {
+				m = strings.ToUpper(m)
+				if _, ok := httpMethods[m]; !ok {
+					return nil, fmt.Errorf("rule %q: unknown criterion %q", s, tok)
+				}
+				r.Methods = append(r.Methods, m)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Matches returns true if the request described by method, path,
+// status and latency matches all criteria of r.
+func (r *Rule) Matches(method, reqPath string, status int, latency time.Duration) bool {
+	if r.Path != "" && !matchPath(r.Path, reqPath) {
+		return false
+	}
+
+	if len(r.Methods) > 0 {
+		found := false
+		for _, m := range r.Methods {
+			if m == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.StatusClass != 0 && status/100 != r.StatusClass {
+		return false
+	}
+
+	if r.MinLatency > 0 && latency < r.MinLatency {
+		return false
+	}
+
+	return true
+}
+
+// shouldLog returns true if a request matching r should be logged.
+func (r *Rule) shouldLog() bool {
+	switch r.Action {
+	case ActionSkip:
+		return false
+	case ActionSample:
+		return (atomic.AddUint64(&r.counter, 1)-1)%r.SampleRate == 0
+	default:
+		return true
+	}
+}
+
+func matchPath(pattern, reqPath string) bool {
+	if strings.HasSuffix(pattern, "/**") {
+		prefix := strings.TrimSuffix(pattern, "**")
+		if strings.HasPrefix(reqPath, prefix) || reqPath+"/" == prefix {
+			return true
+		}
+	}
+
+	matched, _ := path.Match(pattern, reqPath)
+	return matched
+}
@@ -0,0 +1,67 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/assert"
+)
+
+func Test_ParseRule(t *testing.T) {
+	r, err := ParseRule("sample:10 get,head /static/** status=2xx latency>=10ms")
+	assert.NilError(t, err)
+	assert.Equal(t, ActionSample, r.Action)
+	assert.Equal(t, uint64(10), r.SampleRate)
+	assert.DeepEqual(t, []string{"GET", "HEAD"}, r.Methods)
+	assert.Equal(t, "/static/**", r.Path)
+	assert.Equal(t, 2, r.StatusClass)
+	assert.Equal(t, 10*time.Millisecond, r.MinLatency)
+
+	assert.Assert(t, r.Matches("GET", "/static/js/app.js", 200, time.Second))
+	assert.Assert(t, r.Matches("HEAD", "/static", 204, time.Second))
+	assert.Assert(t, !r.Matches("POST", "/static/js/app.js", 200, time.Second))
+	assert.Assert(t, !r.Matches("GET", "/staticfoo", 200, time.Second))
+	assert.Assert(t, !r.Matches("GET", "/static/js/app.js", 404, time.Second))
+	assert.Assert(t, !r.Matches("GET", "/static/js/app.js", 200, time.Millisecond))
+
+	for _, invalid := range []string{"", "drop /", "sample:0 /", "sample:x /", "log status=6xx", "log latency>=fast", "log /[a", "skip healthz", "skip GET,FETCH /", "log get,"} {
+		_, err := ParseRule(invalid)
+		assert.Assert(t, err != nil, invalid)
+	}
+}
+
+func Test_RequestLoggerRules(t *testing.T) {
+	rules := []*Rule{}
+	for _, s := range []string{"skip /healthz", "sample:3 /static/**"} {
+		r, err := ParseRule(s)
+		assert.NilError(t, err)
+		rules = append(rules, r)
+	}
+
+	count := 0
+	engine := gin.New()
+	engine.Use(New(countingLogger(&count), WithRules(rules...)))
+	engine.GET("/*path", func(c *gin.Context) {
+		status := http.StatusOK
+		if c.Query("fail") != "" {
+			status = http.StatusInternalServerError
+		}
+		c.Status(status)
+	})
+
+	send := func(url string, n int) int {
+		count = 0
+		for i := 0; i < n; i++ {
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+		}
+		return count
+	}
+
+	assert.Equal(t, 0, send("/healthz", 10))
+	assert.Equal(t, 10, send("/healthz?fail=1", 10))
+	assert.Equal(t, 3, send("/static/app.js", 9))
+	assert.Equal(t, 5, send("/api/patients", 5))
+}
@@ -0,0 +1,262 @@
+package accesslog
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// SeverityWarning is the severity used to report slow requests.
+// The logger package only defines Info and Error so warnings
+// are logged with a severity between both.
+const SeverityWarning = logger.Severity(3)
+
+// UnmatchedRoute is used as the route name for requests that
+// did not match any route.
+const UnmatchedRoute = "<unmatched>"
+
+// LatencyBuckets are the upper bounds of the histogram buckets
+// used by SlowRequests.
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// SlowRequests detects requests that exceed a latency threshold
+// and keeps a latency histogram for each route in memory.
+// Thresholds can be configured per gin route template.
+type SlowRequests struct {
+	log              logger.Logger
+	defaultThreshold time.Duration
+
+	rw         sync.RWMutex
+	thresholds map[string]time.Duration
+	histograms map[string]*histogram
+}
+
+// NewSlowRequests returns a new slow request detector that reports
+// to log. defaultThreshold is used for all routes without a specific
+// threshold. A threshold of zero disables reporting but latencies
+// are still recorded.
+func NewSlowRequests(log logger.Logger, defaultThreshold time.Duration) *SlowRequests {
+	return &SlowRequests{
+		log:              log,
+		defaultThreshold: defaultThreshold,
+		thresholds:       make(map[string]time.Duration),
+		histograms:       make(map[string]*histogram),
+	}
+}
+
+// SetThreshold configures the threshold for route. route is the
+// gin route template (like /patients/:id), optionally prefixed
+// with a HTTP method (like "GET /patients/:id").
+func (s *SlowRequests) SetThreshold(route string, d time.Duration) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	s.thresholds[normalizeRouteKey(route)] = d
+}
+
+// ParseThreshold parses a route threshold in the format
+// "[METHOD] <route> <duration>" and applies it to s.
+func (s *SlowRequests) ParseThreshold(spec string) error {
+	tokens := strings.Fields(spec)
+	if len(tokens) < 2 || len(tokens) > 3 {
+		return fmt.Errorf("invalid slow route %q", spec)
+	}
+
+	d, err := time.ParseDuration(tokens[len(tokens)-1])
+	if err != nil {
+		return fmt.Errorf("invalid slow route %q: %w", spec, err)
+	}
+
+	s.SetThreshold(strings.Join(tokens[:len(tokens)-1], " "), d)
+
+	return nil
+}
+
+// Threshold returns the threshold for method and route.
+func (s *SlowRequests) Threshold(method, route string) time.Duration {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	if d, ok := s.thresholds[method+" "+route]; ok {
+		return d
+	}
+	if d, ok := s.thresholds[route]; ok {
+		return d
+	}
+
+	return s.defaultThreshold
+}
+
+// Observe records the latency of the request in c and logs a warning
+// if it exceeds the threshold of the request route. fields are added
+// to the warning.
+func (s *SlowRequests) Observe(c *gin.Context, latency time.Duration, fields logger.Fields) {
+	route := c.FullPath()
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	key := c.Request.Method + " " + route
+
+	s.rw.Lock()
+	h, ok := s.histograms[key]
+	if !ok {
+		h = newHistogram()
+		s.histograms[key] = h
+	}
+	h.observe(latency)
+	s.rw.Unlock()
+
+	threshold := s.Threshold(c.Request.Method, route)
+	if threshold <= 0 || latency < threshold {
+		return
+	}
+
+	warnFields := logger.Fields{
+		FieldRoute:      route,
+		FieldHandler:    c.HandlerName(),
+		FieldThreshold:  threshold.String(),
+		FieldLatency:    latency.String(),
+		FieldLatencyRaw: latency,
+	}
+	for k, v := range fields {
+		if _, ok := warnFields[k]; !ok {
+			warnFields[k] = v
+		}
+	}
+
+	s.log.WithFields(warnFields).V(SeverityWarning).Logf("slow request: %s %s took %s (threshold %s)", c.Request.Method, route, latency, threshold)
+}
+
+// RouteLatency describes the latency histogram of a route.
+type RouteLatency struct {
+	Method  string          `json:"method"`
+	Route   string          `json:"route"`
+	Count   uint64          `json:"count"`
+	Sum     time.Duration   `json:"sum"`
+	Max     time.Duration   `json:"max"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket is a cumulative histogram bucket holding the number
+// of requests that took at most Le.
+type LatencyBucket struct {
+	Le    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// Mean returns the mean latency of the route.
+func (rl RouteLatency) Mean() time.Duration {
+	if rl.Count == 0 {
+		return 0
+	}
+	return rl.Sum / time.Duration(rl.Count)
+}
+
+// Histograms returns a snapshot of all route histograms sorted by
+// route and method.
+func (s *SlowRequests) Histograms() []RouteLatency {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	result := make([]RouteLatency, 0, len(s.histograms))
+	for key, h := range s.histograms {
+		parts := strings.SplitN(key, " ", 2)
+		result = append(result, h.snapshot(parts[0], parts[1]))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Route != result[j].Route {
+			return result[i].Route < result[j].Route
+		}
+		return result[i].Method < result[j].Method
+	})
+
+	return result
+}
+
+// Handler returns a gin handler that responds with all route
+// histograms encoded as JSON.
+func (s *SlowRequests) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.Histograms())
+	}
+}
+
+// normalizeRouteKey upper-cases the method part of a route
+// key.
+func normalizeRouteKey(route string) string {
+	parts := strings.Fields(route)
+	if len(parts) == 2 {
+		return strings.ToUpper(parts[0]) + " " + parts[1]
+	}
+
+	return route
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{
+		counts: make([]uint64, len(LatencyBuckets)+1),
+	}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	idx := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return d <= LatencyBuckets[i]
+	})
+	h.counts[idx]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *histogram) snapshot(method, route string) RouteLatency {
+	rl := RouteLatency{
+		Method:  method,
+		Route:   route,
+		Count:   h.count,
+		Sum:     h.sum,
+		Max:     h.max,
+		Buckets: make([]LatencyBucket, len(h.counts)),
+	}
+
+	var cumulative uint64
+	for idx, c := range h.counts {
+		cumulative += c
+		le := "+Inf"
+		if idx < len(LatencyBuckets) {
+			le = LatencyBuckets[idx].String()
+		}
+		rl.Buckets[idx] = LatencyBucket{Le: le, Count: cumulative}
+	}
+
+	return rl
+}
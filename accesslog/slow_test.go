@@ -0,0 +1,54 @@
+package accesslog
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func Test_SlowRequests(t *testing.T) {
+	var warnings []logger.Fields
+	log := logger.New(logger.AdapterFunc(func(_ time.Time, s logger.Severity, _ string, f logger.Fields) {
+		if s == SeverityWarning {
+			warnings = append(warnings, f)
+		}
+	}))
+
+	slow := NewSlowRequests(log, time.Hour)
+	assert.NilError(t, slow.ParseThreshold("get /patients/:id 5ms"))
+	assert.Assert(t, slow.ParseThreshold("/patients") != nil)
+
+	engine := gin.New()
+	engine.Use(New(log, WithSlowRequests(slow)))
+	engine.GET("/patients/:id", func(c *gin.Context) {
+		c.Set("user", "max")
+		if c.Param("id") == "slow" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Status(200)
+	})
+	engine.GET("/other", func(c *gin.Context) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	for _, url := range []string{"/patients/1", "/patients/slow", "/other", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+
+	assert.Equal(t, 1, len(warnings))
+	assert.Equal(t, "/patients/:id", warnings[0]["http:route"])
+	assert.Equal(t, "max", warnings[0]["user"])
+	assert.Assert(t, warnings[0]["http:handler"] != "")
+
+	histograms := slow.Histograms()
+	assert.Equal(t, 3, len(histograms))
+	assert.Equal(t, "/other", histograms[0].Route)
+	assert.Equal(t, "/patients/:id", histograms[1].Route)
+	assert.Equal(t, UnmatchedRoute, histograms[2].Route)
+	assert.Equal(t, uint64(2), histograms[1].Count)
+	assert.Equal(t, uint64(2), histograms[1].Buckets[len(histograms[1].Buckets)-1].Count)
+}
@@ -0,0 +1,20 @@
+// Command accesslog prints statistics about access log files
+// written by the built-in HTTP server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tierklinik-dobersberg/service/accesslog"
+)
+
+func main() {
+	if err := accesslog.QueryCommand(os.Args[1:], os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,270 @@
+// Package health provides a registry of named health checks and
+// HTTP handlers that expose their aggregated result as JSON for
+// liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is used for checks that don't specify a timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Status is the status of a single check or of a whole report.
+type Status string
+
+// Available check and report status values.
+const (
+	// StatusOK is used if all checks passed.
+	StatusOK Status = "ok"
+
+	// StatusDegraded is used if only non-critical checks failed.
+	StatusDegraded Status = "degraded"
+
+	// StatusFailing is used if a critical check failed or the
+	// service is shutting down.
+	StatusFailing Status = "failing"
+)
+
+// CheckFunc checks the health of a component. It should return
+// as soon as ctx is cancelled.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named health check.
+type Check struct {
+	// Name is the unique name of the check.
+	Name string
+
+	// Func is called to perform the check.
+	Func CheckFunc
+
+	// Timeout is the maximum time Func may take before the check
+	// is considered failed. Defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// Critical marks the check as critical. A failing critical
+	// check fails the whole report while non-critical checks
+	// only degrade it.
+	Critical bool
+
+	// Liveness includes the check in liveness reports. Liveness
+	// checks should only fail if the process cannot recover
+	// without a restart. All checks are part of readiness
+	// reports.
+	Liveness bool
+}
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the aggregated result of all checks.
+type Report struct {
+	Status       Status                 `json:"status"`
+	ShuttingDown bool                   `json:"shuttingDown,omitempty"`
+	Checks       map[string]CheckResult `json:"checks"`
+}
+
+// Registry holds health checks. It is safe for concurrent use.
+type Registry struct {
+	rw           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown int32
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]Check),
+	}
+}
+
+// Register adds check to the registry. The name of the check
+// must be unique.
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" {
+		return errors.New("health check without name")
+	}
+	if check.Func == nil {
+		return fmt.Errorf("health check %s: no check function", check.Name)
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	if _, ok := r.checks[check.Name]; ok {
+		return fmt.Errorf("health check %s: already registered", check.Name)
+	}
+	r.checks[check.Name] = check
+
+	return nil
+}
+
+// Unregister removes the check with the given name.
+func (r *Registry) Unregister(name string) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	delete(r.checks, name)
+}
+
+// Names returns the sorted names of all registered checks.
+func (r *Registry) Names() []string {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SetShuttingDown marks the service as shutting down. Readiness
+// reports fail from now on.
+func (r *Registry) SetShuttingDown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// ShuttingDown returns true if SetShuttingDown has been called.
+func (r *Registry) ShuttingDown() bool {
+	return atomic.LoadInt32(&r.shuttingDown) == 1
+}
+
+// Liveness runs all liveness checks and returns the report.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(c Check) bool { return c.Liveness })
+}
+
+// Readiness runs all checks and returns the report. The report
+// is failing if the service is shutting down.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.run(ctx, func(Check) bool { return true })
+	if r.ShuttingDown() {
+		report.ShuttingDown = true
+		report.Status = StatusFailing
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, include func(Check) bool) Report {
+	r.rw.RLock()
+	var checks []Check
+	for _, c := range r.checks {
+		if include(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.rw.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for idx := range checks {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			results[idx] = runCheck(ctx, checks[idx])
+		}(idx)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	for idx, res := range results {
+		report.Checks[checks[idx].Name] = res
+
+		if res.Status == StatusOK {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusFailing
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// the check runs in a separate goroutine so a check that
+	// ignores ctx cannot block the whole report.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{
+		Status:   StatusOK,
+		Critical: check.Critical,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// LivenessHandler returns a http.Handler that serves the liveness
+// report.
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadinessHandler returns a http.Handler that serves the
+// readiness report.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+// reportHandler serves the report returned by fn as JSON. Failing
+// reports are served with 503 Service Unavailable.
+func reportHandler(fn func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := fn(req.Context())
+
+		code := http.StatusOK
+		if report.Status == StatusFailing {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("broken") }
+
+func Test_Register(t *testing.T) {
+	r := NewRegistry()
+	assert.NilError(t, r.Register(Check{Name: "db", Func: ok}))
+	assert.ErrorContains(t, r.Register(Check{Name: "db", Func: ok}), "already registered")
+	assert.ErrorContains(t, r.Register(Check{Func: ok}), "without name")
+	assert.ErrorContains(t, r.Register(Check{Name: "cache"}), "no check function")
+
+	assert.DeepEqual(t, []string{"db"}, r.Names())
+	r.Unregister("db")
+	assert.DeepEqual(t, []string{}, r.Names())
+}
+
+func Test_Readiness(t *testing.T) {
+	r := NewRegistry()
+	assert.NilError(t, r.Register(Check{Name: "db", Func: ok, Critical: true}))
+
+	report := r.Readiness(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["db"].Status)
+
+	assert.NilError(t, r.Register(Check{Name: "cache", Func: fail}))
+	report = r.Readiness(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, "broken", report.Checks["cache"].Error)
+
+	assert.NilError(t, r.Register(Check{Name: "queue", Func: fail, Critical: true}))
+	report = r.Readiness(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+}
+
+func Test_Timeout(t *testing.T) {
+	r := NewRegistry()
+	block := make(chan struct{})
+	defer close(block)
+
+	// the check ignores its context and must still time out.
+	assert.NilError(t, r.Register(Check{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  10 * time.Millisecond,
+		Func: func(context.Context) error {
+			<-block
+			return nil
+		},
+	}))
+
+	report := r.Readiness(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func Test_Liveness(t *testing.T) {
+	r := NewRegistry()
+	assert.NilError(t, r.Register(Check{Name: "db", Func: fail, Critical: true}))
+	assert.NilError(t, r.Register(Check{Name: "deadlock", Func: ok, Critical: true, Liveness: true}))
+
+	report := r.Liveness(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, 1, len(report.Checks))
+
+	// shutting down only affects readiness.
+	r.SetShuttingDown()
+	assert.Equal(t, StatusOK, r.Liveness(context.Background()).Status)
+}
+
+func Test_Handler(t *testing.T) {
+	r := NewRegistry()
+	assert.NilError(t, r.Register(Check{Name: "db", Func: ok, Critical: true}))
+
+	serve := func(h http.Handler) (int, Report) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+		var report Report
+		assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		return rec.Code, report
+	}
+
+	code, report := serve(r.ReadinessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+
+	r.SetShuttingDown()
+	code, report = serve(r.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Assert(t, report.ShuttingDown)
+
+	code, _ = serve(r.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,165 @@
+// Package lifecycle manages named components that are started in
+// dependency order, supervised using a restart policy and stopped
+// in reverse order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Component is a part of the service that needs to be started
+// and stopped together with it.
+type Component interface {
+	// Start starts the component. It should return as soon as
+	// the component is running. ctx is only valid during the
+	// call to Start.
+	Start(ctx context.Context) error
+
+	// Stop stops the component. It should return once the
+	// component is stopped or ctx is cancelled.
+	Stop(ctx context.Context) error
+}
+
+// Supervised is implemented by components that may fail after
+// Start returned. Failed components are restarted according to
+// their restart policy.
+type Supervised interface {
+	Component
+
+	// Done returns a channel that is closed when the component
+	// exits after it has been started.
+	Done() <-chan struct{}
+
+	// Err returns the error that caused the component to exit.
+	// It returns nil if the component has been stopped using
+	// Stop or exited without an error.
+	Err() error
+}
+
+// RestartPolicy configures how failed components are restarted.
+// The zero value disables restarts.
+type RestartPolicy struct {
+	// MaxRestarts is the maximum number of restarts. A negative
+	// value allows unlimited restarts.
+	MaxRestarts int
+
+	// Backoff is the delay before the first restart. It's doubled
+	// after each restart. It must be set if MaxRestarts allows
+	// unlimited restarts.
+	Backoff time.Duration
+
+	// MaxBackoff limits the delay between restarts. Zero means
+	// no limit.
+	MaxBackoff time.Duration
+}
+
+// delay returns the backoff delay before the given restart.
+func (p RestartPolicy) delay(restart int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < restart && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	return d
+}
+
+// allowed returns true if the given restart is allowed.
+func (p RestartPolicy) allowed(restart int) bool {
+	return p.MaxRestarts < 0 || restart <= p.MaxRestarts
+}
+
+// Worker returns a supervised component that runs fn in a
+// separate goroutine until Stop is called. The context passed to
+// fn is cancelled when the component is stopped.
+func Worker(fn func(ctx context.Context) error) Supervised {
+	return &worker{fn: fn}
+}
+
+type worker struct {
+	fn func(ctx context.Context) error
+
+	l       sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+	stopped bool
+}
+
+func (w *worker) Start(_ context.Context) error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.done != nil {
+		select {
+		case <-w.done:
+		default:
+			return errors.New("worker already running")
+		}
+	}
+
+	// the worker must outlive the start context.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	w.cancel = cancel
+	w.done = done
+	w.err = nil
+	w.stopped = false
+
+	go func() {
+		err := w.fn(ctx)
+
+		w.l.Lock()
+		if !w.stopped {
+			w.err = err
+		}
+		w.l.Unlock()
+
+		cancel()
+		close(done)
+	}()
+
+	return nil
+}
+
+func (w *worker) Stop(ctx context.Context) error {
+	w.l.Lock()
+	if w.done == nil {
+		w.l.Unlock()
+		return nil
+	}
+	w.stopped = true
+	w.cancel()
+	done := w.done
+	w.l.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *worker) Done() <-chan struct{} {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	return w.done
+}
+
+func (w *worker) Err() error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	return w.err
+}
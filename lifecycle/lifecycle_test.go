@@ -0,0 +1,281 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+type recorder struct {
+	l      sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+type fakeComponent struct {
+	name     string
+	rec      *recorder
+	startErr []error
+}
+
+func (f *fakeComponent) Start(context.Context) error {
+	if len(f.startErr) > 0 {
+		err := f.startErr[0]
+		f.startErr = f.startErr[1:]
+		if err != nil {
+			f.rec.add("fail " + f.name)
+			return err
+		}
+	}
+	f.rec.add("start " + f.name)
+	return nil
+}
+
+func (f *fakeComponent) Stop(context.Context) error {
+	f.rec.add("stop " + f.name)
+	return nil
+}
+
+func newManager() *Manager {
+	return NewManager(logger.DefaultLogger())
+}
+
+func Test_Order(t *testing.T) {
+	rec := new(recorder)
+	m := newManager()
+	assert.NilError(t, m.Register("api", &fakeComponent{name: "api", rec: rec}, DependsOn("db", "cache")))
+	assert.NilError(t, m.Register("cache", &fakeComponent{name: "cache", rec: rec}))
+	assert.NilError(t, m.Register("db", &fakeComponent{name: "db", rec: rec}))
+	assert.ErrorContains(t, m.Register("db", &fakeComponent{}), "already registered")
+
+	names, err := m.Names()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"db", "cache", "api"}, names)
+
+	assert.NilError(t, m.Start(context.Background()))
+	assert.ErrorContains(t, m.Register("late", &fakeComponent{}), "already started")
+	assert.NilError(t, m.Stop(context.Background()))
+
+	assert.DeepEqual(t, []string{
+		"start db", "start cache", "start api",
+		"stop api", "stop cache", "stop db",
+	}, rec.get())
+}
+
+func Test_OrderErrors(t *testing.T) {
+	m := newManager()
+	assert.NilError(t, m.Register("a", &fakeComponent{}, DependsOn("b")))
+	assert.NilError(t, m.Register("b", &fakeComponent{}, DependsOn("a")))
+	assert.ErrorContains(t, m.Start(context.Background()), "dependency cycle")
+
+	m = newManager()
+	assert.NilError(t, m.Register("a", &fakeComponent{}, DependsOn("missing")))
+	assert.ErrorContains(t, m.Start(context.Background()), "unknown dependency missing")
+}
+
+func Test_StartFailure(t *testing.T) {
+	rec := new(recorder)
+	m := newManager()
+	assert.NilError(t, m.Register("db", &fakeComponent{name: "db", rec: rec}))
+	assert.NilError(t, m.Register("api", &fakeComponent{
+		name:     "api",
+		rec:      rec,
+		startErr: []error{errors.New("boom"), errors.New("boom")},
+	}, WithRestartPolicy(RestartPolicy{MaxRestarts: 1})))
+
+	assert.ErrorContains(t, m.Start(context.Background()), "component api: giving up after 1 restarts")
+	assert.DeepEqual(t, []string{"start db", "fail api", "fail api", "stop db"}, rec.get())
+}
+
+func Test_StartRetry(t *testing.T) {
+	rec := new(recorder)
+	m := newManager()
+	assert.NilError(t, m.Register("api", &fakeComponent{
+		name:     "api",
+		rec:      rec,
+		startErr: []error{errors.New("boom")},
+	}, WithRestartPolicy(RestartPolicy{MaxRestarts: 1, Backoff: time.Millisecond})))
+
+	assert.NilError(t, m.Start(context.Background()))
+	assert.NilError(t, m.Stop(context.Background()))
+	assert.DeepEqual(t, []string{"fail api", "start api", "stop api"}, rec.get())
+}
+
+func Test_StartCancelBackoff(t *testing.T) {
+	m := newManager()
+	assert.NilError(t, m.Register("api", &fakeComponent{
+		name:     "api",
+		rec:      new(recorder),
+		startErr: []error{errors.New("boom")},
+	}, WithRestartPolicy(RestartPolicy{MaxRestarts: -1, Backoff: time.Hour})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the backoff must not block a cancelled start.
+	assert.Assert(t, errors.Is(m.Start(ctx), context.DeadlineExceeded))
+}
+
+func Test_RegisterUnlimitedRestarts(t *testing.T) {
+	m := newManager()
+	err := m.Register("api", Worker(func(context.Context) error { return nil }), WithRestartPolicy(RestartPolicy{MaxRestarts: -1}))
+	assert.ErrorContains(t, err, "unlimited restarts require a backoff")
+}
+
+func Test_WorkerRestart(t *testing.T) {
+	var (
+		l    sync.Mutex
+		runs int
+	)
+	worker := Worker(func(ctx context.Context) error {
+		l.Lock()
+		runs++
+		n := runs
+		l.Unlock()
+
+		if n < 3 {
+			return errors.New("crashed")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	m := newManager()
+	assert.NilError(t, m.Register("sync", worker, WithRestartPolicy(RestartPolicy{
+		MaxRestarts: -1,
+		Backoff:     time.Millisecond,
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.Lock()
+		n := runs
+		l.Unlock()
+		if n >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker was not restarted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NilError(t, m.Stop(context.Background()))
+	assert.NilError(t, worker.Err())
+
+	select {
+	case err := <-m.Failed():
+		t.Fatalf("unexpected failure: %s", err)
+	default:
+	}
+}
+
+func Test_WorkerFailed(t *testing.T) {
+	m := newManager()
+	assert.NilError(t, m.Register("sync", Worker(func(ctx context.Context) error {
+		return errors.New("crashed")
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	select {
+	case err := <-m.Failed():
+		assert.ErrorContains(t, err, "component sync: crashed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure not reported")
+	}
+
+	assert.NilError(t, m.Stop(context.Background()))
+}
+
+func Test_StopTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	m := newManager()
+	assert.NilError(t, m.Register("stuck", Worker(func(ctx context.Context) error {
+		<-block
+		return nil
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := m.Stop(ctx)
+	assert.ErrorContains(t, err, "component stuck")
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func Test_RestartPolicyDelay(t *testing.T) {
+	p := RestartPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+	assert.Equal(t, 5*time.Second, p.delay(50))
+}
+
+func Test_Done(t *testing.T) {
+	m := newManager()
+	assert.NilError(t, m.Register("once", Worker(func(ctx context.Context) error {
+		return nil
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	select {
+	case <-m.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("manager not done")
+	}
+	assert.NilError(t, m.Stop(context.Background()))
+
+	// components that are not supervised never finish.
+	m = newManager()
+	assert.NilError(t, m.Register("db", &fakeComponent{rec: new(recorder)}))
+	assert.NilError(t, m.Register("once", Worker(func(ctx context.Context) error {
+		return nil
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	select {
+	case <-m.Done():
+		t.Fatal("manager must not be done")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.NilError(t, m.Stop(context.Background()))
+	// background components are ignored.
+	m = newManager()
+	assert.NilError(t, m.Register("db", &fakeComponent{rec: new(recorder)}, Background()))
+	assert.NilError(t, m.Register("sync", Worker(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}), Background()))
+	assert.NilError(t, m.Register("once", Worker(func(ctx context.Context) error {
+		return nil
+	})))
+	assert.NilError(t, m.Start(context.Background()))
+
+	select {
+	case <-m.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("manager not done")
+	}
+	assert.NilError(t, m.Stop(context.Background()))
+}
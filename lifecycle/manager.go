@@ -0,0 +1,417 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Option configures a registered component.
+type Option func(*entry)
+
+// DependsOn declares that the component requires the named
+// components. They are started before and stopped after the
+// component.
+func DependsOn(names ...string) Option {
+	return func(e *entry) {
+		e.deps = append(e.deps, names...)
+	}
+}
+
+// WithRestartPolicy sets the restart policy of the component.
+func WithRestartPolicy(p RestartPolicy) Option {
+	return func(e *entry) {
+		e.policy = p
+	}
+}
+
+// Background marks the component as running in the background.
+// Done does not wait for background components.
+func Background() Option {
+	return func(e *entry) {
+		e.background = true
+	}
+}
+
+type entry struct {
+	name       string
+	c          Component
+	deps       []string
+	policy     RestartPolicy
+	background bool
+
+	l       sync.Mutex
+	running bool
+}
+
+// Manager starts, supervises and stops components.
+type Manager struct {
+	log logger.Logger
+
+	l        sync.Mutex
+	entries  []*entry
+	started  []*entry
+	state    int
+	stopping chan struct{}
+	failed   chan error
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+const (
+	stateNew = iota
+	stateStarted
+	stateStopped
+)
+
+// NewManager returns a new manager that logs to log.
+func NewManager(log logger.Logger) *Manager {
+	return &Manager{
+		log:      log,
+		stopping: make(chan struct{}),
+		failed:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register registers the component c under name. Components must
+// be registered before Start is called.
+func (m *Manager) Register(name string, c Component, opts ...Option) error {
+	if name == "" {
+		return errors.New("component without name")
+	}
+
+	e := &entry{name: name, c: c}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.policy.MaxRestarts < 0 && e.policy.Backoff <= 0 {
+		// a failing component would be restarted in a busy loop.
+		return fmt.Errorf("component %s: unlimited restarts require a backoff", name)
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.state != stateNew {
+		return fmt.Errorf("component %s: manager already started", name)
+	}
+	for _, other := range m.entries {
+		if other.name == name {
+			return fmt.Errorf("component %s: already registered", name)
+		}
+	}
+	m.entries = append(m.entries, e)
+
+	return nil
+}
+
+// Names returns the names of all components in start order.
+func (m *Manager) Names() ([]string, error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	order, err := m.order()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(order))
+	for idx, e := range order {
+		names[idx] = e.name
+	}
+
+	return names, nil
+}
+
+// order returns all entries sorted by their dependencies. Entries
+// without dependencies between them keep the registration order.
+func (m *Manager) order() ([]*entry, error) {
+	byName := make(map[string]*entry, len(m.entries))
+	for _, e := range m.entries {
+		byName[e.name] = e
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(m.entries))
+	order := make([]*entry, 0, len(m.entries))
+
+	var visit func(e *entry, path []string) error
+	visit = func(e *entry, path []string) error {
+		switch marks[e.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", append(path, e.name))
+		}
+
+		marks[e.name] = visiting
+		for _, dep := range e.deps {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("component %s: unknown dependency %s", e.name, dep)
+			}
+			if err := visit(d, append(path, e.name)); err != nil {
+				return err
+			}
+		}
+		marks[e.name] = visited
+		order = append(order, e)
+
+		return nil
+	}
+
+	for _, e := range m.entries {
+		if err := visit(e, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Start starts all components in dependency order. Components
+// that fail to start are retried according to their restart
+// policy. If a component cannot be started all components that
+// have already been started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.state != stateNew {
+		return errors.New("manager already started")
+	}
+
+	order, err := m.order()
+	if err != nil {
+		return err
+	}
+	m.state = stateStarted
+
+	// foreground counts all components that are not running in
+	// the background and supervised those of them that are
+	// supervised. exited tracks when they exit.
+	var (
+		foreground int
+		supervised int
+		exited     sync.WaitGroup
+	)
+	for _, e := range order {
+		if err := m.startEntry(ctx, e); err != nil {
+			close(m.stopping)
+			m.state = stateStopped
+			if stopErr := m.stopEntries(ctx); stopErr != nil {
+				m.log.Errorf("failed to stop components: %s", stopErr)
+			}
+			m.wg.Wait()
+
+			return fmt.Errorf("component %s: %w", e.name, err)
+		}
+		m.started = append(m.started, e)
+
+		if !e.background {
+			foreground++
+		}
+
+		s, ok := e.c.(Supervised)
+		if !ok {
+			continue
+		}
+
+		m.wg.Add(1)
+		if e.background {
+			go m.supervise(e, s)
+			continue
+		}
+
+		supervised++
+		exited.Add(1)
+		go func(e *entry, s Supervised) {
+			defer exited.Done()
+			m.supervise(e, s)
+		}(e, s)
+	}
+
+	// if all foreground components are supervised we know when
+	// all of them have exited.
+	if supervised > 0 && supervised == foreground {
+		go func() {
+			exited.Wait()
+			close(m.done)
+		}()
+	}
+
+	return nil
+}
+
+// startEntry starts e and retries according to its restart
+// policy.
+func (m *Manager) startEntry(ctx context.Context, e *entry) error {
+	for restart := 0; ; restart++ {
+		if restart > 0 {
+			if !e.policy.allowed(restart) {
+				return fmt.Errorf("giving up after %d restarts", restart-1)
+			}
+			if err := m.wait(ctx, e.policy.delay(restart)); err != nil {
+				return err
+			}
+		}
+
+		m.log.V(5).Logf("starting component %s", e.name)
+		e.l.Lock()
+		err := e.c.Start(ctx)
+		e.running = err == nil
+		e.l.Unlock()
+
+		if err == nil {
+			return nil
+		}
+		m.log.Errorf("failed to start component %s: %s", e.name, err)
+	}
+}
+
+// wait waits for d or until ctx is cancelled or the manager is
+// stopped.
+func (m *Manager) wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.stopping:
+		return errors.New("manager stopped")
+	}
+}
+
+// supervise restarts s whenever it fails until the manager is
+// stopped. If the restart policy is exhausted the failure is
+// reported on Failed.
+func (m *Manager) supervise(e *entry, s Supervised) {
+	defer m.wg.Done()
+
+	for restart := 1; ; restart++ {
+		select {
+		case <-s.Done():
+		case <-m.stopping:
+			return
+		}
+
+		err := s.Err()
+
+		e.l.Lock()
+		e.running = false
+		e.l.Unlock()
+
+		if err == nil {
+			m.log.V(5).Logf("component %s exited", e.name)
+			return
+		}
+
+		if !e.policy.allowed(restart) {
+			m.fail(fmt.Errorf("component %s: %w", e.name, err))
+			return
+		}
+
+		delay := e.policy.delay(restart)
+		m.log.Errorf("component %s failed: %s, restarting in %s", e.name, err, delay)
+		if m.wait(context.Background(), delay) != nil {
+			return
+		}
+
+		e.l.Lock()
+		// the manager might have been stopped while we were
+		// waiting for the lock.
+		select {
+		case <-m.stopping:
+			e.l.Unlock()
+			return
+		default:
+		}
+		err = e.c.Start(context.Background())
+		e.running = err == nil
+		e.l.Unlock()
+
+		if err != nil {
+			m.fail(fmt.Errorf("component %s: restart failed: %w", e.name, err))
+			return
+		}
+	}
+}
+
+func (m *Manager) fail(err error) {
+	m.log.Errorf("%s", err)
+
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Done returns a channel that is closed once all components have
+// exited. This only happens if all components except those
+// registered using Background are supervised (see Supervised) as
+// there's no way to know when other components exit. If a component failed, Failed receives the
+// error before Done is closed.
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
+}
+
+// Failed returns a channel that receives an error if a supervised
+// component failed and could not be restarted.
+func (m *Manager) Failed() <-chan error {
+	return m.failed
+}
+
+// Stop stops all started components in reverse start order. Stop
+// continues after errors and returns the first one.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.state != stateStarted {
+		return nil
+	}
+	m.state = stateStopped
+	close(m.stopping)
+
+	err := m.stopEntries(ctx)
+
+	// wait for all supervisors to notice the stop.
+	m.wg.Wait()
+
+	return err
+}
+
+func (m *Manager) stopEntries(ctx context.Context) error {
+	var firstErr error
+	for idx := len(m.started) - 1; idx >= 0; idx-- {
+		e := m.started[idx]
+
+		e.l.Lock()
+		if e.running {
+			m.log.V(5).Logf("stopping component %s", e.name)
+			if err := e.c.Stop(ctx); err != nil {
+				m.log.Errorf("failed to stop component %s: %s", e.name, err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("component %s: %w", e.name, err)
+				}
+			}
+			e.running = false
+		}
+		e.l.Unlock()
+	}
+	m.started = nil
+
+	return firstErr
+}
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Supported values for the Output option of the [Log] section.
+const (
+	OutputStderr   = "stderr"
+	OutputJournald = "journald"
+	OutputSyslog   = "syslog"
+)
+
+// Config holds the logging configuration as read from the [Log]
+// section.
+type Config struct {
+	// Level holds the log levels in the format supported by
+	// Levels.Parse, like "info,server=debug".
+	Level string
+
+	// Output holds the names of all log outputs that should be
+	// enabled. See OutputStderr, OutputJournald and OutputSyslog.
+	Output []string
+
+	// Identifier is used as the syslog APP-NAME and the journald
+	// SYSLOG_IDENTIFIER.
+	Identifier string
+
+	// JournaldSocket is the path to the native journald socket.
+	JournaldSocket string
+
+	// SyslogAddress is the address of the syslog server. See
+	// SyslogAdapter.Address for supported formats.
+	SyslogAddress string
+
+	// SyslogFacility is the name of the syslog facility.
+	SyslogFacility string
+
+	// BufferSize is the number of log entries kept in memory.
+	// See RingBuffer.
+	BufferSize int
+}
+
+// Adapters returns all log adapters configured in cfg. Errors of
+// the journald and syslog adapters are reported to a
+// logger.StdlibAdapter.
+func (cfg Config) Adapters() ([]logger.Adapter, error) {
+	var adapters []logger.Adapter
+
+	for _, output := range cfg.Output {
+		switch strings.ToLower(output) {
+		case OutputStderr:
+			adapters = append(adapters, new(logger.StdlibAdapter))
+
+		case OutputJournald:
+			adapters = append(adapters, &JournaldAdapter{
+				SocketPath:   cfg.JournaldSocket,
+				Identifier:   cfg.Identifier,
+				ErrorAdapter: new(logger.StdlibAdapter),
+			})
+
+		case OutputSyslog:
+			facility := "daemon"
+			if cfg.SyslogFacility != "" {
+				facility = cfg.SyslogFacility
+			}
+			f, err := ParseFacility(facility)
+			if err != nil {
+				return nil, err
+			}
+
+			adapters = append(adapters, &SyslogAdapter{
+				Address:      cfg.SyslogAddress,
+				Facility:     f,
+				AppName:      cfg.Identifier,
+				ErrorAdapter: new(logger.StdlibAdapter),
+			})
+
+		default:
+			return nil, fmt.Errorf("unsupported log output %q", output)
+		}
+	}
+
+	return adapters, nil
+}
+
+// ConfigSpec defines the available configuration values for the
+// [Log] section.
+var ConfigSpec = conf.SectionSpec{
+	{
+		Name:        "Level",
+		Description: "Comma separated log levels. Either a default level (error, warning, info, debug or a number) or component=level pairs, like \"info,server=debug,accesslog=error\". Component names may contain wildcards.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "Output",
+		Description: "Log outputs to enable. One of stderr, journald or syslog.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "Identifier",
+		Description: "The identifier used for syslog and journald messages. Defaults to the name of the executable.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "JournaldSocket",
+		Description: "Path to the native journald socket.",
+		Type:        conf.StringType,
+		Default:     DefaultJournaldSocket,
+	},
+	{
+		Name:        "SyslogAddress",
+		Description: "Address of the syslog server in the format <udp|tcp|unix>://<address>.",
+		Type:        conf.StringType,
+		Default:     DefaultSyslogAddress,
+	},
+	{
+		Name:        "SyslogFacility",
+		Description: "The syslog facility to use.",
+		Type:        conf.StringType,
+		Default:     "daemon",
+	},
+	{
+		Name:        "BufferSize",
+		Description: "Number of recent log entries kept in memory and exposed on the administrative endpoints.",
+		Type:        conf.IntType,
+		Default:     strconv.Itoa(DefaultRingBufferSize),
+	},
+}
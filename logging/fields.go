@@ -0,0 +1,20 @@
+package logging
+
+// Well-known log fields.
+const (
+	// ComponentField is the log field that holds the name of the
+	// component that emitted a log message. See Named.
+	ComponentField = "component"
+
+	// RequestIDField is the log field that holds the ID of the HTTP
+	// request a log message belongs to.
+	RequestIDField = "requestId"
+
+	// TraceIDField is the log field that holds the OpenTelemetry
+	// trace ID of the request a log message belongs to.
+	TraceIDField = "traceId"
+
+	// SpanIDField is the log field that holds the OpenTelemetry
+	// span ID of the request a log message belongs to.
+	SpanIDField = "spanId"
+)
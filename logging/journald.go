@@ -0,0 +1,186 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// DefaultJournaldSocket is the path of the native journald socket.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// now is replaced during tests.
+var now = time.Now
+
+// JournaldAdapter is a logger.Adapter that writes log messages to
+// journald using its native socket protocol. Log fields are sent
+// as journal fields with their names converted to upper-case and
+// all unsupported characters replaced by underscores, so
+// "http:status" becomes HTTP_STATUS. Fields that would clash with
+// journal fields set by the adapter or interpreted by journald are
+// prefixed with FIELD_ (so "message" becomes FIELD_MESSAGE). Note
+// that messages that
+// exceed the maximum datagram size of the socket are dropped and
+// reported to ErrorAdapter.
+type JournaldAdapter struct {
+	// SocketPath is the path of the journald socket. It defaults
+	// to DefaultJournaldSocket.
+	SocketPath string
+
+	// Identifier is sent as SYSLOG_IDENTIFIER. If empty, the field
+	// is not set and journald uses the name of the process.
+	Identifier string
+
+	// ErrorAdapter, if set, receives errors encountered while
+	// sending messages to journald. It must not write to the
+	// JournaldAdapter itself.
+	ErrorAdapter logger.Adapter
+
+	l    sync.Mutex
+	conn *net.UnixConn
+}
+
+// Write implements logger.Adapter.
+func (j *JournaldAdapter) Write(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+	var buf bytes.Buffer
+
+	writeJournalField(&buf, "MESSAGE", msg)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(Priority(severity)))
+	if j.Identifier != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", j.Identifier)
+	}
+	writeJournalField(&buf, "SYSLOG_TIMESTAMP", clock.Format(time.RFC3339Nano))
+
+	for _, key := range sortedKeys(fields) {
+		writeJournalField(&buf, JournalFieldName(key), fmt.Sprint(fields[key]))
+	}
+
+	if err := j.send(buf.Bytes()); err != nil {
+		reportError(j.ErrorAdapter, "failed to write to journald", err)
+	}
+}
+
+// Close closes the connection to journald.
+func (j *JournaldAdapter) Close() error {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+func (j *JournaldAdapter) send(payload []byte) error {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	// retry once with a new connection in case journald has been
+	// restarted.
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if j.conn == nil {
+			if err = j.dial(); err != nil {
+				return err
+			}
+		}
+
+		if _, err = j.conn.Write(payload); err == nil {
+			return nil
+		}
+
+		j.conn.Close()
+		j.conn = nil
+	}
+
+	return err
+}
+
+func (j *JournaldAdapter) dial() error {
+	path := j.SocketPath
+	if path == "" {
+		path = DefaultJournaldSocket
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	j.conn = conn
+	return nil
+}
+
+// writeJournalField writes a single field in the journald native
+// protocol format to buf. Values that contain new-lines are sent
+// using the binary, length-prefixed format.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// reservedJournalFields holds journal fields that are either set by
+// JournaldAdapter or have a special meaning for journald.
+var reservedJournalFields = map[string]struct{}{
+	"MESSAGE":            {},
+	"MESSAGE_ID":         {},
+	"PRIORITY":           {},
+	"CODE_FILE":          {},
+	"CODE_LINE":          {},
+	"CODE_FUNC":          {},
+	"ERRNO":              {},
+	"INVOCATION_ID":      {},
+	"USER_INVOCATION_ID": {},
+	"SYSLOG_FACILITY":    {},
+	"SYSLOG_IDENTIFIER":  {},
+	"SYSLOG_PID":         {},
+	"SYSLOG_TIMESTAMP":   {},
+	"SYSLOG_RAW":         {},
+	"DOCUMENTATION":      {},
+	"TID":                {},
+	"UNIT":               {},
+	"USER_UNIT":          {},
+}
+
+// JournalFieldName converts the log field key into a valid journal
+// field name. Journal field names may only contain upper-case letters,
+// digits and underscores, must not start with an underscore or digit
+// and are limited to 64 characters. Names of reserved journal fields
+// like MESSAGE or PRIORITY are prefixed with FIELD_ so log fields
+// cannot overwrite them.
+func JournalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for idx, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[idx] = '_'
+		}
+	}
+
+	result := strings.TrimLeft(string(name), "_")
+	if _, ok := reservedJournalFields[result]; ok || result == "" || (result[0] >= '0' && result[0] <= '9') {
+		result = "FIELD_" + result
+	}
+	if len(result) > 64 {
+		result = result[:64]
+	}
+
+	return result
+}
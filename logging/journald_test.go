@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+// parseJournalPayload parses a datagram in the journald native
+// protocol format.
+func parseJournalPayload(t *testing.T, payload []byte) map[string]string {
+	result := make(map[string]string)
+	for len(payload) > 0 {
+		idx := bytes.IndexAny(payload, "=\n")
+		assert.Assert(t, idx > 0)
+
+		name := string(payload[:idx])
+		if payload[idx] == '=' {
+			end := bytes.IndexByte(payload, '\n')
+			result[name] = string(payload[idx+1 : end])
+			payload = payload[end+1:]
+			continue
+		}
+
+		size := binary.LittleEndian.Uint64(payload[idx+1 : idx+9])
+		result[name] = string(payload[idx+9 : idx+9+int(size)])
+		payload = payload[idx+9+int(size)+1:]
+	}
+	return result
+}
+
+func Test_JournaldAdapter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	adapter := &JournaldAdapter{
+		SocketPath: path,
+		Identifier: "test-service",
+	}
+	defer adapter.Close()
+
+	clock := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	adapter.Write(clock, logger.Error, "failed\nbadly", logger.Fields{
+		"http:status": 500,
+		"_private":    "x",
+		"1st":         true,
+		"message":     "user message",
+		"_priority":   "high",
+	})
+
+	buf := make([]byte, 4096)
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	assert.NilError(t, err)
+
+	fields := parseJournalPayload(t, buf[:n])
+	assert.DeepEqual(t, map[string]string{
+		"MESSAGE":           "failed\nbadly",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "test-service",
+		"SYSLOG_TIMESTAMP":  "2021-10-01T12:00:00Z",
+		"HTTP_STATUS":       "500",
+		"PRIVATE":           "x",
+		"FIELD_1ST":         "true",
+		"FIELD_MESSAGE":     "user message",
+		"FIELD_PRIORITY":    "high",
+	}, fields)
+}
+
+func Test_JournalFieldName(t *testing.T) {
+	cases := map[string]string{
+		"http:status":         "HTTP_STATUS",
+		"http:request-header": "HTTP_REQUEST_HEADER",
+		"__x":                 "X",
+		"":                    "FIELD_",
+		"9":                   "FIELD_9",
+		"message":             "FIELD_MESSAGE",
+		"Syslog-Identifier":   "FIELD_SYSLOG_IDENTIFIER",
+		"message_text":        "MESSAGE_TEXT",
+	}
+	for key, expected := range cases {
+		assert.Equal(t, expected, JournalFieldName(key), key)
+	}
+}
+
+func Test_Priority(t *testing.T) {
+	assert.Equal(t, PriorityErr, Priority(logger.Error))
+	assert.Equal(t, PriorityWarning, Priority(3))
+	assert.Equal(t, PriorityNotice, Priority(4))
+	assert.Equal(t, PriorityInfo, Priority(logger.Info))
+	assert.Equal(t, PriorityDebug, Priority(7))
+}
@@ -0,0 +1,312 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Named log levels. Any other level is represented by its
+// numeric value.
+const (
+	LevelError   = logger.Error
+	LevelWarning = logger.Severity(3)
+	LevelInfo    = logger.Info
+	LevelDebug   = logger.Severity(7)
+)
+
+var levelNames = []struct {
+	name  string
+	level logger.Severity
+}{
+	{"error", LevelError},
+	{"warning", LevelWarning},
+	{"info", LevelInfo},
+	{"debug", LevelDebug},
+}
+
+// ParseLevel parses a named (error, warning, info, debug) or
+// numeric log level.
+func ParseLevel(s string) (logger.Severity, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warn" {
+		s = "warning"
+	}
+	for _, l := range levelNames {
+		if l.name == s {
+			return l.level, nil
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return logger.Severity(n), nil
+}
+
+// LevelName returns the name of level or its numeric value if
+// it's not a named level.
+func LevelName(level logger.Severity) string {
+	for _, l := range levelNames {
+		if l.level == level {
+			return l.name
+		}
+	}
+	return strconv.Itoa(int(level))
+}
+
+// StepUp returns the next named level that is more verbose than
+// level. LevelDebug and above are returned unchanged.
+func StepUp(level logger.Severity) logger.Severity {
+	for _, l := range levelNames {
+		if l.level > level {
+			return l.level
+		}
+	}
+	return level
+}
+
+// StepDown returns the next named level that is less verbose than
+// level. LevelError is returned unchanged.
+func StepDown(level logger.Severity) logger.Severity {
+	for idx := len(levelNames) - 1; idx >= 0; idx-- {
+		if levelNames[idx].level < level {
+			return levelNames[idx].level
+		}
+	}
+	return level
+}
+
+// Named returns a logger that adds name as ComponentField to all
+// log messages.
+func Named(log logger.Logger, name string) logger.Logger {
+	return log.WithFields(logger.Fields{ComponentField: name})
+}
+
+// NamedContext returns a new context that adds name as
+// ComponentField to all log messages emitted using logger.From.
+func NamedContext(ctx context.Context, name string) context.Context {
+	// logger.WithFields and logger.With share the same context
+	// key so we need to store a new logger instead of fields.
+	return logger.With(ctx, Named(logger.From(ctx), name))
+}
+
+// WithComponent returns an adapter that adds name as ComponentField
+// before passing log messages to adapter. Other than Named, the
+// component is not visible to any other adapter.
+func WithComponent(adapter logger.Adapter, name string) logger.Adapter {
+	return logger.AdapterFunc(func(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+		f := make(logger.Fields, len(fields)+1)
+		for k, v := range fields {
+			f[k] = v
+		}
+		f[ComponentField] = name
+
+		adapter.Write(clock, severity, msg, f)
+	})
+}
+
+// Levels holds a default log level and individual levels for
+// components. Component names may be patterns as supported by
+// path.Match like "server/*". Levels is safe for concurrent use.
+type Levels struct {
+	rw         sync.RWMutex
+	def        logger.Severity
+	components map[string]logger.Severity
+}
+
+// NewLevels returns a new Levels with the default level def.
+func NewLevels(def logger.Severity) *Levels {
+	return &Levels{
+		def:        def,
+		components: make(map[string]logger.Severity),
+	}
+}
+
+// Default returns the default log level.
+func (l *Levels) Default() logger.Severity {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+	return l.def
+}
+
+// SetDefault sets the default log level used for all components
+// without an individual level.
+func (l *Levels) SetDefault(level logger.Severity) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+	l.def = level
+}
+
+// Set sets the log level for component.
+func (l *Levels) Set(component string, level logger.Severity) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+	l.components[component] = level
+}
+
+// Reset removes the individual log level of component.
+func (l *Levels) Reset(component string) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+	delete(l.components, component)
+}
+
+// Components returns a copy of all individual component levels.
+func (l *Levels) Components() map[string]logger.Severity {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	result := make(map[string]logger.Severity, len(l.components))
+	for k, v := range l.components {
+		result[k] = v
+	}
+	return result
+}
+
+// For returns the log level for component. A level that is set
+// for the exact name of component takes precedence over patterns.
+// If multiple patterns match, the longest one wins.
+func (l *Levels) For(component string) logger.Severity {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	if component == "" || len(l.components) == 0 {
+		return l.def
+	}
+
+	if level, ok := l.components[component]; ok {
+		return level
+	}
+
+	level, best := l.def, ""
+	for pattern, lvl := range l.components {
+		if len(pattern) <= len(best) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, component); ok {
+			level, best = lvl, pattern
+		}
+	}
+
+	return level
+}
+
+// Enabled returns true if a log message with severity should be
+// logged for component.
+func (l *Levels) Enabled(component string, severity logger.Severity) bool {
+	return severity <= l.For(component)
+}
+
+// Parse parses a comma separated list of levels and applies it to
+// l. Each entry is either a level (setting the default) or a
+// component=level pair, like "info,server=debug,accesslog=error".
+func (l *Levels) Parse(spec string) error {
+	def := l.Default()
+	components := make(map[string]logger.Severity)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 1 {
+			level, err := ParseLevel(parts[0])
+			if err != nil {
+				return err
+			}
+			def = level
+			continue
+		}
+
+		component := strings.TrimSpace(parts[0])
+		if _, err := path.Match(component, ""); err != nil || component == "" {
+			return fmt.Errorf("invalid component %q", component)
+		}
+
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return fmt.Errorf("component %s: %w", component, err)
+		}
+		components[component] = level
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	l.def = def
+	for k, v := range components {
+		l.components[k] = v
+	}
+
+	return nil
+}
+
+// Reload replaces the levels applied from oldSpec with the ones
+// from newSpec. Both specs are relative to the default level def
+// as supported by Parse. Levels that have been changed since
+// oldSpec was applied, for example using SetDefault, Set or Reset,
+// are kept. l is not modified if newSpec is invalid.
+func (l *Levels) Reload(def logger.Severity, oldSpec, newSpec string) error {
+	next := NewLevels(def)
+	if err := next.Parse(newSpec); err != nil {
+		return err
+	}
+
+	prev := NewLevels(def)
+	if err := prev.Parse(oldSpec); err != nil {
+		// treat everything as changed at runtime.
+		prev = NewLevels(def)
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	if l.def != prev.def {
+		next.def = l.def
+	}
+	for component, level := range l.components {
+		if p, ok := prev.components[component]; !ok || p != level {
+			next.components[component] = level
+		}
+	}
+	for component := range prev.components {
+		if _, ok := l.components[component]; !ok {
+			delete(next.components, component)
+		}
+	}
+
+	l.def = next.def
+	l.components = next.components
+
+	return nil
+}
+
+// String returns the levels in the format supported by Parse.
+func (l *Levels) String() string {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	parts := []string{LevelName(l.def)}
+	names := make([]string, 0, len(l.components))
+	for name := range l.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		parts = append(parts, name+"="+LevelName(l.components[name]))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func Test_ParseLevel(t *testing.T) {
+	cases := map[string]logger.Severity{
+		"error":   LevelError,
+		"WARN":    LevelWarning,
+		"warning": LevelWarning,
+		"info":    LevelInfo,
+		" debug ": LevelDebug,
+		"10":      logger.Severity(10),
+	}
+	for input, expected := range cases {
+		level, err := ParseLevel(input)
+		assert.NilError(t, err, input)
+		assert.Equal(t, expected, level, input)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.ErrorContains(t, err, "invalid log level")
+
+	_, err = ParseLevel("-1")
+	assert.ErrorContains(t, err, "invalid log level")
+}
+
+func Test_Levels(t *testing.T) {
+	levels := NewLevels(LevelError)
+	assert.NilError(t, levels.Parse("info, server=debug, server/*=warning, accesslog=error"))
+
+	assert.Equal(t, LevelInfo, levels.For(""))
+	assert.Equal(t, LevelInfo, levels.For("config"))
+	assert.Equal(t, LevelDebug, levels.For("server"))
+	assert.Equal(t, LevelWarning, levels.For("server/bind"))
+	assert.Equal(t, LevelError, levels.For("accesslog"))
+
+	assert.Assert(t, levels.Enabled("server", LevelDebug))
+	assert.Assert(t, !levels.Enabled("accesslog", LevelInfo))
+
+	assert.Equal(t, "info,accesslog=error,server=debug,server/*=warning", levels.String())
+
+	levels.Reset("server")
+	assert.Equal(t, LevelInfo, levels.For("server"))
+
+	// invalid specs must not be applied partially
+	assert.ErrorContains(t, levels.Parse("debug,server=loud"), "component server")
+	assert.Equal(t, LevelInfo, levels.Default())
+	assert.Equal(t, LevelInfo, levels.For("server"))
+}
+
+func Test_LevelsReload(t *testing.T) {
+	l := NewLevels(LevelInfo)
+	assert.NilError(t, l.Parse("debug,server=error"))
+
+	assert.NilError(t, l.Reload(LevelInfo, "debug,server=error", "accesslog=warning"))
+	assert.Equal(t, LevelInfo, l.Default())
+	assert.Equal(t, LevelInfo, l.For("server"))
+	assert.Equal(t, LevelWarning, l.For("accesslog"))
+
+	assert.Assert(t, l.Reload(LevelInfo, "accesslog=warning", "server=foo") != nil)
+	assert.Equal(t, LevelInfo, l.Default())
+	assert.Equal(t, LevelWarning, l.For("accesslog"))
+
+	// levels changed at runtime survive a reload.
+	l.SetDefault(LevelDebug)
+	l.Set("scheduler", LevelError)
+	l.Reset("accesslog")
+	assert.NilError(t, l.Reload(LevelInfo, "accesslog=warning", "warning,accesslog=debug,server=error"))
+	assert.Equal(t, LevelDebug, l.Default())
+	assert.Equal(t, LevelError, l.For("scheduler"))
+	assert.Equal(t, LevelError, l.For("server"))
+	assert.Equal(t, "debug,scheduler=error,server=error", l.String())
+}
+
+func Test_StepLevel(t *testing.T) {
+	assert.Equal(t, LevelWarning, StepUp(LevelError))
+	assert.Equal(t, LevelInfo, StepUp(LevelWarning))
+	assert.Equal(t, LevelInfo, StepUp(4))
+	assert.Equal(t, LevelDebug, StepUp(LevelDebug))
+
+	assert.Equal(t, LevelInfo, StepDown(LevelDebug))
+	assert.Equal(t, LevelInfo, StepDown(6))
+	assert.Equal(t, LevelError, StepDown(LevelError))
+}
+
+func Test_WithComponent(t *testing.T) {
+	var fields logger.Fields
+	adapter := WithComponent(logger.AdapterFunc(func(_ time.Time, _ logger.Severity, _ string, f logger.Fields) {
+		fields = f
+	}), "accesslog")
+
+	original := logger.Fields{"http:status": 200}
+	logger.New(adapter).WithFields(original).Info("request")
+
+	assert.Equal(t, "accesslog", fields[ComponentField])
+	assert.Equal(t, 200, fields["http:status"])
+	_, ok := original[ComponentField]
+	assert.Assert(t, !ok)
+}
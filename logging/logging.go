@@ -0,0 +1,59 @@
+// Package logging extends the logger package with per-component
+// log levels, an in-memory ring buffer of recent log entries, helpers
+// for well-known log fields and logger.Adapter implementations that
+// write to journald and syslog.
+package logging
+
+import (
+	"sort"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Syslog priorities as used by syslog and journald.
+const (
+	PriorityErr     = 3
+	PriorityWarning = 4
+	PriorityNotice  = 5
+	PriorityInfo    = 6
+	PriorityDebug   = 7
+)
+
+// Priority maps a logger severity to a syslog priority. Severities
+// between logger.Error and logger.Info are mapped to warning and
+// notice while everything more verbose than logger.Info is mapped
+// to debug.
+func Priority(severity logger.Severity) int {
+	switch {
+	case severity <= logger.Error:
+		return PriorityErr
+	case severity < logger.Info-1:
+		return PriorityWarning
+	case severity < logger.Info:
+		return PriorityNotice
+	case severity == logger.Info:
+		return PriorityInfo
+	default:
+		return PriorityDebug
+	}
+}
+
+// reportError reports err to adapter if it's set.
+func reportError(adapter logger.Adapter, msg string, err error) {
+	if adapter == nil {
+		return
+	}
+	adapter.Write(now(), logger.Error, msg, logger.Fields{
+		"error": err.Error(),
+	})
+}
+
+// sortedKeys returns the keys of fields in sorted order.
+func sortedKeys(fields logger.Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,291 @@
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// DefaultRingBufferSize is the default number of log entries kept
+// by a RingBuffer.
+const DefaultRingBufferSize = 1000
+
+// Entry is a log message stored in a RingBuffer.
+type Entry struct {
+	ID       uint64          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Severity logger.Severity `json:"severity"`
+	Level    string          `json:"level"`
+	Msg      string          `json:"msg"`
+	Fields   logger.Fields   `json:"fields,omitempty"`
+}
+
+// Component returns the component that emitted the entry.
+func (e Entry) Component() string {
+	c, _ := e.Fields[ComponentField].(string)
+	return c
+}
+
+// RequestID returns the request ID of the entry.
+func (e Entry) RequestID() string {
+	id, _ := e.Fields[RequestIDField].(string)
+	return id
+}
+
+// Filter selects log entries from a RingBuffer. Zero values
+// match all entries.
+type Filter struct {
+	// MaxSeverity, if set, only matches entries with a severity
+	// lower or equal to *MaxSeverity.
+	MaxSeverity *logger.Severity
+
+	// Component only matches entries of the component.
+	Component string
+
+	// RequestID only matches entries of the request.
+	RequestID string
+
+	// Since only matches entries logged at or after Since.
+	Since time.Time
+
+	// Until only matches entries logged before Until.
+	Until time.Time
+
+	// AfterID only matches entries with a greater ID.
+	AfterID uint64
+
+	// Limit limits the number of entries returned by
+	// RingBuffer.Entries to the most recent ones.
+	Limit int
+}
+
+// Matches returns true if e matches f. Limit is ignored.
+func (f Filter) Matches(e Entry) bool {
+	if f.MaxSeverity != nil && e.Severity > *f.MaxSeverity {
+		return false
+	}
+	if f.Component != "" && e.Component() != f.Component {
+		return false
+	}
+	if f.RequestID != "" && e.RequestID() != f.RequestID {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if e.ID <= f.AfterID {
+		return false
+	}
+	return true
+}
+
+// ParseFilter parses a filter from URL query values. Supported
+// parameters are severity (a log level), component, requestId,
+// since and until (RFC3339 timestamps or durations relative to
+// now, like 15m), after (an entry ID) and limit.
+func ParseFilter(values url.Values) (Filter, error) {
+	var f Filter
+
+	if s := values.Get("severity"); s != "" {
+		level, err := ParseLevel(s)
+		if err != nil {
+			return f, err
+		}
+		f.MaxSeverity = &level
+	}
+
+	f.Component = values.Get("component")
+	f.RequestID = values.Get("requestId")
+
+	var err error
+	if f.Since, err = parseFilterTime(values.Get("since")); err != nil {
+		return f, fmt.Errorf("invalid since: %w", err)
+	}
+	if f.Until, err = parseFilterTime(values.Get("until")); err != nil {
+		return f, fmt.Errorf("invalid until: %w", err)
+	}
+
+	if s := values.Get("after"); s != "" {
+		if f.AfterID, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid after: %w", err)
+		}
+	}
+
+	if s := values.Get("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil {
+			return f, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+func parseFilterTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// RingBuffer is a logger.Adapter that keeps the most recent log
+// entries in memory and supports subscribing to new entries.
+type RingBuffer struct {
+	l       sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	lastID  uint64
+	subs    map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch      chan Entry
+	filter  Filter
+	dropped uint64
+}
+
+// NewRingBuffer returns a new ring buffer that keeps the last size
+// log entries.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = DefaultRingBufferSize
+	}
+	return &RingBuffer{
+		entries: make([]Entry, size),
+		subs:    make(map[*subscription]struct{}),
+	}
+}
+
+// Write implements logger.Adapter.
+func (r *RingBuffer) Write(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+	// fields must not be retained as it may be used by the
+	// caller for other messages.
+	var copied logger.Fields
+	if len(fields) > 0 {
+		copied = make(logger.Fields, len(fields))
+		for k, v := range fields {
+			copied[k] = v
+		}
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.lastID++
+	e := Entry{
+		ID:       r.lastID,
+		Time:     clock,
+		Severity: severity,
+		Level:    LevelName(severity),
+		Msg:      msg,
+		Fields:   copied,
+	}
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	for sub := range r.subs {
+		if !sub.filter.Matches(e) {
+			continue
+		}
+		// never block the logger on slow subscribers.
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Resize changes the number of entries kept by r. If size is lower
+// than the current number of entries, the oldest ones are dropped.
+func (r *RingBuffer) Resize(size int) {
+	if size <= 0 {
+		size = DefaultRingBufferSize
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if size == len(r.entries) {
+		return
+	}
+
+	entries := r.snapshot()
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+
+	r.entries = make([]Entry, size)
+	r.next = copy(r.entries, entries) % size
+	r.full = len(entries) == size
+}
+
+// snapshot returns all entries, oldest first. The caller must hold
+// r.l.
+func (r *RingBuffer) snapshot() []Entry {
+	var result []Entry
+	if r.full {
+		result = append(result, r.entries[r.next:]...)
+	}
+	return append(result, r.entries[:r.next]...)
+}
+
+// Entries returns all entries that match filter, oldest first.
+func (r *RingBuffer) Entries(filter Filter) []Entry {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	var result []Entry
+	for _, e := range r.snapshot() {
+		if filter.Matches(e) {
+			result = append(result, e)
+		}
+	}
+
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+
+	return result
+}
+
+// Subscribe returns a channel that receives all new entries that
+// match filter. Entries are dropped if the channel buffer is full.
+// The returned function must be called to cancel the subscription
+// and returns the number of dropped entries.
+func (r *RingBuffer) Subscribe(filter Filter, buffer int) (<-chan Entry, func() uint64) {
+	sub := &subscription{
+		ch:     make(chan Entry, buffer),
+		filter: filter,
+	}
+
+	r.l.Lock()
+	r.subs[sub] = struct{}{}
+	r.l.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() uint64 {
+		r.l.Lock()
+		defer r.l.Unlock()
+
+		once.Do(func() {
+			delete(r.subs, sub)
+		})
+
+		return sub.dropped
+	}
+}
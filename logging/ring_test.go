@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func msgs(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for idx, e := range entries {
+		result[idx] = e.Msg
+	}
+	return result
+}
+
+func Test_RingBuffer(t *testing.T) {
+	buf := NewRingBuffer(3)
+	start := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for idx, msg := range []string{"a", "b", "c", "d"} {
+		buf.Write(start.Add(time.Duration(idx)*time.Minute), logger.Info, msg, logger.Fields{
+			ComponentField: "server",
+		})
+	}
+
+	entries := buf.Entries(Filter{})
+	assert.DeepEqual(t, []string{"b", "c", "d"}, msgs(entries))
+	assert.Equal(t, uint64(4), entries[2].ID)
+	assert.Equal(t, "info", entries[2].Level)
+
+	assert.DeepEqual(t, []string{"c", "d"}, msgs(buf.Entries(Filter{Limit: 2})))
+	assert.DeepEqual(t, []string{"d"}, msgs(buf.Entries(Filter{AfterID: 3})))
+	assert.DeepEqual(t, []string{"c"}, msgs(buf.Entries(Filter{
+		Since: start.Add(2 * time.Minute),
+		Until: start.Add(3 * time.Minute),
+	})))
+
+	buf.Resize(2)
+	assert.DeepEqual(t, []string{"c", "d"}, msgs(buf.Entries(Filter{})))
+
+	buf.Resize(4)
+	buf.Write(start, logger.Error, "e", nil)
+	assert.DeepEqual(t, []string{"c", "d", "e"}, msgs(buf.Entries(Filter{})))
+}
+
+func Test_RingBufferFilter(t *testing.T) {
+	buf := NewRingBuffer(10)
+	fields := logger.Fields{ComponentField: "server", RequestIDField: "req-1"}
+	buf.Write(time.Now(), logger.Error, "error", fields)
+	buf.Write(time.Now(), logger.Info, "info", fields)
+	buf.Write(time.Now(), logger.Info, "other", logger.Fields{ComponentField: "config"})
+
+	// fields must be copied
+	fields["extra"] = true
+	assert.Equal(t, 2, len(buf.Entries(Filter{})[0].Fields))
+
+	filter, err := ParseFilter(url.Values{"severity": {"error"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"error"}, msgs(buf.Entries(filter)))
+
+	filter, err = ParseFilter(url.Values{"component": {"server"}, "requestId": {"req-1"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"error", "info"}, msgs(buf.Entries(filter)))
+
+	filter, err = ParseFilter(url.Values{"since": {"1h"}})
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(buf.Entries(filter)))
+
+	_, err = ParseFilter(url.Values{"until": {"yesterday"}})
+	assert.ErrorContains(t, err, "invalid until")
+}
+
+func Test_RingBufferSubscribe(t *testing.T) {
+	buf := NewRingBuffer(10)
+
+	level := LevelError
+	ch, cancel := buf.Subscribe(Filter{MaxSeverity: &level}, 1)
+
+	buf.Write(time.Now(), logger.Info, "skipped", nil)
+	buf.Write(time.Now(), logger.Error, "first", nil)
+	buf.Write(time.Now(), logger.Error, "dropped", nil)
+
+	e := <-ch
+	assert.Equal(t, "first", e.Msg)
+	assert.Equal(t, uint64(1), cancel())
+
+	// no entries are delivered after cancel
+	buf.Write(time.Now(), logger.Error, "late", nil)
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected entry %q", e.Msg)
+	default:
+	}
+}
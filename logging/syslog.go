@@ -0,0 +1,286 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// DefaultSyslogAddress is the address of the local syslog daemon.
+const DefaultSyslogAddress = "unix:///dev/log"
+
+// DefaultSyslogTimeout is the default timeout for connecting and
+// writing to the syslog server.
+const DefaultSyslogTimeout = 5 * time.Second
+
+// StructuredDataID is the SD-ID used for log fields sent as RFC 5424
+// structured data.
+const StructuredDataID = "fields@32473"
+
+// Syslog facilities as defined in RFC 5424.
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// ParseFacility returns the numeric value of the syslog facility
+// name.
+func ParseFacility(name string) (int, error) {
+	f, ok := facilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return f, nil
+}
+
+// SyslogAdapter is a logger.Adapter that writes RFC 5424 formatted
+// messages to a syslog server. Log fields are sent as structured
+// data using StructuredDataID.
+type SyslogAdapter struct {
+	// Address is the address of the syslog server in the format
+	// <network>://<address> where network is one of udp, tcp or
+	// unix, like udp://127.0.0.1:514 or unix:///dev/log. Messages
+	// sent via TCP use octet-counting framing as defined in
+	// RFC 6587. Address defaults to DefaultSyslogAddress.
+	Address string
+
+	// Facility is the numeric syslog facility. See ParseFacility.
+	// A zero value means "kern" so users likely want to set it.
+	Facility int
+
+	// AppName is sent as the APP-NAME of each message. It
+	// defaults to the name of the executable.
+	AppName string
+
+	// Timeout bounds connecting and each write to the syslog
+	// server so a stalled server does not block logging. It
+	// defaults to DefaultSyslogTimeout. Messages that cannot be
+	// written in time are reported to ErrorAdapter.
+	Timeout time.Duration
+
+	// ErrorAdapter, if set, receives errors encountered while
+	// sending messages to syslog. It must not write to the
+	// SyslogAdapter itself.
+	ErrorAdapter logger.Adapter
+
+	l        sync.Mutex
+	conn     net.Conn
+	framed   bool
+	hostname string
+}
+
+// Write implements logger.Adapter.
+func (s *SyslogAdapter) Write(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	payload := s.format(clock, severity, msg, fields)
+
+	// retry once with a new connection in case the syslog
+	// server has been restarted.
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.dial(); err != nil {
+				break
+			}
+		}
+
+		if err = s.conn.SetWriteDeadline(time.Now().Add(s.timeout())); err == nil {
+			if _, err = s.conn.Write(s.frame(payload)); err == nil {
+				return
+			}
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	reportError(s.ErrorAdapter, "failed to write to syslog", err)
+}
+
+// Close closes the connection to the syslog server.
+func (s *SyslogAdapter) Close() error {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogAdapter) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultSyslogTimeout
+}
+
+func (s *SyslogAdapter) dial() error {
+	address := s.Address
+	if address == "" {
+		address = DefaultSyslogAddress
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("invalid syslog address %q: %w", address, err)
+	}
+
+	var (
+		conn   net.Conn
+		framed bool
+	)
+	switch u.Scheme {
+	case "udp":
+		conn, err = net.DialTimeout("udp", u.Host, s.timeout())
+	case "tcp":
+		conn, err = net.DialTimeout("tcp", u.Host, s.timeout())
+		framed = true
+	case "unix":
+		// most local syslog daemons use datagram sockets but
+		// some only support streams.
+		conn, err = net.DialTimeout("unixgram", u.Path, s.timeout())
+		if err != nil {
+			conn, err = net.DialTimeout("unix", u.Path, s.timeout())
+			framed = true
+		}
+	default:
+		return fmt.Errorf("invalid syslog address %q: unsupported network %q", address, u.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.framed = framed
+
+	return nil
+}
+
+// frame applies octet-counting framing for stream connections.
+func (s *SyslogAdapter) frame(payload []byte) []byte {
+	if !s.framed {
+		return payload
+	}
+	return append([]byte(strconv.Itoa(len(payload))+" "), payload...)
+}
+
+// format formats a log message as RFC 5424.
+func (s *SyslogAdapter) format(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) []byte {
+	var buf bytes.Buffer
+
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+
+	appName := s.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - ",
+		s.Facility*8+Priority(severity),
+		clock.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderValue(s.hostname, 255),
+		syslogHeaderValue(appName, 48),
+		os.Getpid(),
+	)
+
+	if len(fields) == 0 {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString("[" + StructuredDataID)
+		for _, key := range sortedKeys(fields) {
+			buf.WriteString(" " + sdParamName(key) + `="`)
+			sdParamValue(&buf, fmt.Sprint(fields[key]))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte(']')
+	}
+
+	if msg != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(msg)
+	}
+
+	return buf.Bytes()
+}
+
+// syslogHeaderValue returns value as a valid RFC 5424 header field.
+// Empty values are replaced with the NILVALUE.
+func syslogHeaderValue(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// sdParamName returns a valid PARAM-NAME for key.
+func sdParamName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+
+	if name == "" {
+		return "_"
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// sdParamValue writes value to buf escaping '"', '\' and ']'.
+func sdParamValue(buf *bytes.Buffer, value string) {
+	for _, r := range value {
+		switch r {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func Test_SyslogAdapterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	facility, err := ParseFacility("local0")
+	assert.NilError(t, err)
+
+	adapter := &SyslogAdapter{
+		Address:  "udp://" + conn.LocalAddr().String(),
+		Facility: facility,
+		AppName:  "test-service",
+	}
+	defer adapter.Close()
+
+	clock := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	adapter.Write(clock, logger.Info, "request", logger.Fields{
+		"http:status": 200,
+		"quote":       `a "b" [c]`,
+	})
+
+	buf := make([]byte, 4096)
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NilError(t, err)
+
+	hostname, _ := os.Hostname()
+	expected := fmt.Sprintf(
+		`<134>1 2021-10-01T12:00:00.000000Z %s test-service %d - [fields@32473 http:status="200" quote="a \"b\" [c\]"] request`,
+		syslogHeaderValue(hostname, 255),
+		os.Getpid(),
+	)
+	assert.Equal(t, expected, string(buf[:n]))
+}
+
+func Test_SyslogAdapterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer l.Close()
+
+	adapter := &SyslogAdapter{
+		Address:  "tcp://" + l.Addr().String(),
+		Facility: 3,
+		AppName:  "test-service",
+	}
+	defer adapter.Close()
+
+	go adapter.Write(time.Now(), logger.Error, "failed", nil)
+
+	conn, err := l.Accept()
+	assert.NilError(t, err)
+	defer conn.Close()
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	r := bufio.NewReader(conn)
+	length, err := r.ReadString(' ')
+	assert.NilError(t, err)
+
+	var size int
+	_, err = fmt.Sscanf(length, "%d ", &size)
+	assert.NilError(t, err)
+
+	msg := make([]byte, size)
+	_, err = r.Read(msg)
+	assert.NilError(t, err)
+
+	assert.Assert(t, strings.HasPrefix(string(msg), "<27>1 "))
+	assert.Assert(t, strings.HasSuffix(string(msg), " - - failed"))
+}
+
+func Test_SyslogAdapterStalled(t *testing.T) {
+	// the server accepts connections but never reads.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer l.Close()
+
+	var reported int32
+	adapter := &SyslogAdapter{
+		Address: "tcp://" + l.Addr().String(),
+		Timeout: 50 * time.Millisecond,
+		ErrorAdapter: logger.AdapterFunc(func(time.Time, logger.Severity, string, logger.Fields) {
+			atomic.AddInt32(&reported, 1)
+		}),
+	}
+	defer adapter.Close()
+
+	// large enough to fill all socket buffers.
+	msg := strings.Repeat("x", 32<<20)
+
+	start := time.Now()
+	adapter.Write(time.Now(), logger.Error, msg, nil)
+	assert.Assert(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reported))
+}
+
+func Test_ParseFacility(t *testing.T) {
+	f, err := ParseFacility("DAEMON")
+	assert.NilError(t, err)
+	assert.Equal(t, 3, f)
+
+	_, err = ParseFacility("unknown")
+	assert.ErrorContains(t, err, "unknown syslog facility")
+}
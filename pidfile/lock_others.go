@@ -0,0 +1,17 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package pidfile
+
+import "os"
+
+// LockSupported reports whether PID files are protected by a file
+// lock on this platform. If false, Acquire only writes the PID file
+// and does not prevent other processes from acquiring it.
+const LockSupported = false
+
+// tryLock is a no-op as file locks are not supported on this
+// platform. The PID file is still written.
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
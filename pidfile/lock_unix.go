@@ -0,0 +1,29 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// LockSupported reports whether PID files are protected by a file
+// lock on this platform.
+const LockSupported = true
+
+// tryLock tries to acquire an exclusive lock on f without
+// blocking. It returns false if the lock is held by another open
+// file.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
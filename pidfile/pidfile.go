@@ -0,0 +1,161 @@
+// Package pidfile implements PID files that are protected by an
+// exclusive file lock so only one process can hold them.
+package pidfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockedError is returned by Acquire if the PID file is locked by
+// another process.
+type LockedError struct {
+	// Path is the path of the PID file.
+	Path string
+
+	// PID is the process ID of the process holding the lock.
+	// It is 0 if the PID file does not contain a valid process
+	// ID.
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s is locked by another process", e.Path)
+	}
+	return fmt.Sprintf("%s is locked by process %d", e.Path, e.PID)
+}
+
+// PIDFile is an acquired PID file.
+type PIDFile struct {
+	path string
+	f    *os.File
+}
+
+// Acquire creates the PID file at path, locks it and writes the
+// process ID of the current process. It returns a *LockedError if
+// another process holds the lock. The lock is released when the
+// process exits even if Release is not called. Locking is only
+// supported on unix systems, see LockSupported.
+func Acquire(path string) (*PIDFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	var f *os.File
+	for attempt := 0; f == nil; attempt++ {
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("failed to lock %s: file keeps being replaced", path)
+		}
+
+		var err error
+		f, err = lockPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writePID(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return &PIDFile{path: path, f: f}, nil
+}
+
+// maxAttempts is the maximum number of attempts to lock a PID
+// file that is replaced concurrently.
+const maxAttempts = 10
+
+// openFile is replaced during tests.
+var openFile = os.OpenFile
+
+// lockPath opens and locks the file at path. It returns a nil file
+// if the locked file has been removed or replaced in the meantime.
+// This happens if we opened the file right before the previous
+// owner released it and another process created a new one.
+func lockPath(path string) (*os.File, error) {
+	f, err := openFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLock(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if !locked {
+		defer f.Close()
+		return nil, &LockedError{Path: path, PID: readPID(f)}
+	}
+
+	same, err := isFileAt(f, path)
+	if err != nil || !same {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// isFileAt returns true if f is the file at path.
+func isFileAt(f *os.File, path string) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	pi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return os.SameFile(fi, pi), nil
+}
+
+func readPID(f *os.File) int {
+	blob, err := ioutil.ReadAll(f)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(blob)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Path returns the path of the PID file.
+func (p *PIDFile) Path() string {
+	return p.path
+}
+
+// Release removes the PID file and releases the lock.
+func (p *PIDFile) Release() error {
+	// remove the file before unlocking it so no other process
+	// can see an unlocked file with our PID.
+	err := os.Remove(p.path)
+	if closeErr := p.f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
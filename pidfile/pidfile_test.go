@@ -0,0 +1,88 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pidfile
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_Acquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "service.pid")
+
+	p, err := Acquire(path)
+	assert.NilError(t, err)
+	assert.Equal(t, path, p.Path())
+
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(blob))
+
+	// flock locks are bound to the open file so a second
+	// Acquire fails even within the same process.
+	_, err = Acquire(path)
+	var locked *LockedError
+	assert.Assert(t, errors.As(err, &locked))
+	assert.Equal(t, os.Getpid(), locked.PID)
+	assert.ErrorContains(t, err, "locked by process "+strconv.Itoa(os.Getpid()))
+
+	assert.NilError(t, p.Release())
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err))
+
+	p, err = Acquire(path)
+	assert.NilError(t, err)
+	assert.NilError(t, p.Release())
+}
+
+func Test_AcquireStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.pid")
+
+	// a PID file left behind by a crashed process is not
+	// locked and can be acquired.
+	assert.NilError(t, ioutil.WriteFile(path, []byte("999999\n"), 0644))
+
+	p, err := Acquire(path)
+	assert.NilError(t, err)
+
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(blob))
+	assert.NilError(t, p.Release())
+}
+
+func Test_AcquireReplaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.pid")
+	assert.NilError(t, ioutil.WriteFile(path, []byte("1\n"), 0644))
+
+	// simulate the previous owner removing the file after we
+	// opened it and another process creating a new one.
+	replaced := false
+	openFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		f, err := os.OpenFile(name, flag, perm)
+		if !replaced {
+			replaced = true
+			assert.NilError(t, os.Remove(path))
+			assert.NilError(t, ioutil.WriteFile(path, []byte("2\n"), 0644))
+		}
+		return f, err
+	}
+	defer func() { openFile = os.OpenFile }()
+
+	p, err := Acquire(path)
+	assert.NilError(t, err)
+	defer p.Release()
+
+	// the file at path must be locked, not the removed one.
+	_, err = Acquire(path)
+	var locked *LockedError
+	assert.Assert(t, errors.As(err, &locked))
+	assert.Equal(t, os.Getpid(), locked.PID)
+}
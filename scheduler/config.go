@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ppacher/system-conf/conf"
+)
+
+// Config describes a job as read from a [Job] section. The job
+// executes a handler registered using Scheduler.Handle.
+type Config struct {
+	// Name is the unique name of the job.
+	Name string
+
+	// Handler is the name of the handler that is executed. It
+	// defaults to Name.
+	Handler string
+
+	// Schedule defines when the job is executed. See
+	// ParseSchedule for supported formats.
+	Schedule string
+
+	// Timezone is the name of the time zone used to evaluate
+	// cron expressions. Defaults to the local time zone.
+	Timezone string
+
+	// Jitter delays each execution by a random duration.
+	Jitter time.Duration
+
+	// PreventOverlap skips executions while a previous
+	// execution is still running.
+	PreventOverlap bool
+
+	// CatchUp executes missed runs once the service starts.
+	CatchUp bool
+
+	// Timeout is the maximum time a single execution may take.
+	Timeout time.Duration
+}
+
+// Job returns the job described by cfg that executes fn.
+func (cfg Config) Job(fn JobFunc) (Job, error) {
+	job := Job{
+		Name:           cfg.Name,
+		Schedule:       cfg.Schedule,
+		Func:           fn,
+		Jitter:         cfg.Jitter,
+		PreventOverlap: cfg.PreventOverlap,
+		CatchUp:        cfg.CatchUp,
+		Timeout:        cfg.Timeout,
+	}
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return Job{}, fmt.Errorf("job %s: %w", cfg.Name, err)
+		}
+		job.Location = loc
+	}
+
+	return job, nil
+}
+
+func (cfg Config) handler() string {
+	if cfg.Handler != "" {
+		return cfg.Handler
+	}
+	return cfg.Name
+}
+
+// ConfigSpec defines the available configuration values for
+// [Job] sections.
+var ConfigSpec = conf.SectionSpec{
+	{
+		Name:        "Name",
+		Description: "The unique name of the job.",
+		Type:        conf.StringType,
+		Required:    true,
+	},
+	{
+		Name:        "Handler",
+		Description: "The name of the job handler registered by the service. Defaults to Name.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "Schedule",
+		Description: "When to execute the job. Either a cron expression (\"0 3 * * *\"), a descriptor like @daily, \"@every 1h\" or a plain duration like 15m.",
+		Type:        conf.StringType,
+		Required:    true,
+	},
+	{
+		Name:        "Timezone",
+		Description: "The time zone used for cron expressions (like Europe/Vienna). Defaults to the local time zone.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "Jitter",
+		Description: "Delay each execution by a random duration up to Jitter.",
+		Type:        conf.DurationType,
+	},
+	{
+		Name:        "PreventOverlap",
+		Description: "Whether or not executions should be skipped while a previous execution is still running.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "CatchUp",
+		Description: "Whether or not a missed execution should be run once the service starts.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "Timeout",
+		Description: "Maximum time a single execution may take.",
+		Type:        conf.DurationType,
+	},
+}
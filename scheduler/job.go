@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobFunc is executed whenever a job is due. ctx is cancelled when
+// the scheduler is stopped or the job timeout is exceeded.
+type JobFunc func(ctx context.Context) error
+
+// Job is a function that is executed periodically.
+type Job struct {
+	// Name is the unique name of the job.
+	Name string
+
+	// Schedule defines when the job is executed. See
+	// ParseSchedule for supported formats.
+	Schedule string
+
+	// Func is executed whenever the job is due.
+	Func JobFunc
+
+	// Location is the time zone used to evaluate cron
+	// expressions. Defaults to time.Local.
+	Location *time.Location
+
+	// Jitter delays each execution by a random duration
+	// between zero and Jitter.
+	Jitter time.Duration
+
+	// PreventOverlap skips executions while a previous
+	// execution of the job is still running.
+	PreventOverlap bool
+
+	// CatchUp executes the job once the scheduler is started
+	// if an execution has been missed while the service was
+	// not running. Requires a state file.
+	CatchUp bool
+
+	// Timeout is the maximum time a single execution may take.
+	// Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Schedule returns the next activation time after a given time.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule parses spec which is either a standard cron
+// expression with five fields ("0 3 * * *"), a descriptor like
+// @daily or @hourly, "@every <duration>" or a plain duration like
+// "15m". Cron expressions may be prefixed with CRON_TZ=<zone> to
+// overwrite the job location.
+func ParseSchedule(spec string) (Schedule, error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid interval %q", spec)
+		}
+		return interval(d), nil
+	}
+
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	return s, nil
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
@@ -0,0 +1,452 @@
+// Package scheduler executes jobs periodically based on cron
+// expressions or intervals.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// Errors returned by Scheduler.Run.
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job already running")
+	ErrStopped     = errors.New("scheduler stopped")
+)
+
+// JobStatus describes the current status of a job.
+type JobStatus struct {
+	State
+
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Timezone string    `json:"timezone"`
+	Next     time.Time `json:"next"`
+	Running  bool      `json:"running"`
+	Runs     uint64    `json:"runs"`
+	Failures uint64    `json:"failures"`
+	Skipped  uint64    `json:"skipped"`
+}
+
+type job struct {
+	Job
+
+	schedule Schedule
+	next     time.Time
+	running  int
+	runs     uint64
+	failures uint64
+	skipped  uint64
+}
+
+// Scheduler executes jobs. It implements lifecycle.Component.
+type Scheduler struct {
+	log       logger.Logger
+	statePath string
+
+	l        sync.Mutex
+	jobs     []*job
+	handlers map[string]JobFunc
+	configs  []Config
+	state    map[string]State
+	rand     *rand.Rand
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopped  bool
+	wg       sync.WaitGroup
+
+	// version is incremented whenever state changes. saved is
+	// the version written to statePath and protected by saveL.
+	version uint64
+	saveL   sync.Mutex
+	saved   uint64
+}
+
+// New returns a new scheduler that persists the state of all jobs
+// in statePath. If statePath is empty the state is not persisted.
+func New(log logger.Logger, statePath string) *Scheduler {
+	return &Scheduler{
+		log:       log,
+		statePath: statePath,
+		handlers:  make(map[string]JobFunc),
+		state:     make(map[string]State),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Add adds a new job. Jobs may be added before or after the
+// scheduler is started.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" {
+		return errors.New("job without name")
+	}
+	if j.Func == nil {
+		return fmt.Errorf("job %s: no job function", j.Name)
+	}
+	if j.Location == nil {
+		j.Location = time.Local
+	}
+
+	sched, err := ParseSchedule(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", j.Name, err)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.add(&job{Job: j, schedule: sched})
+}
+
+func (s *Scheduler) add(j *job) error {
+	if s.find(j.Name) != nil {
+		return fmt.Errorf("job %s: already added", j.Name)
+	}
+	for _, cfg := range s.configs {
+		if cfg.Name == j.Name {
+			return fmt.Errorf("job %s: already configured", j.Name)
+		}
+	}
+	s.jobs = append(s.jobs, j)
+
+	if s.ctx != nil && !s.stopped {
+		s.wg.Add(1)
+		go s.loop(s.ctx, j)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) find(name string) *job {
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+// Empty returns true if there are neither jobs nor handlers.
+func (s *Scheduler) Empty() bool {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return len(s.jobs) == 0 && len(s.configs) == 0 && len(s.handlers) == 0
+}
+
+// Handle registers fn as the handler with the given name. Jobs
+// configured using AddConfig execute the handler with the name
+// set in Config.Handler. Handlers must be registered before the
+// scheduler is started.
+func (s *Scheduler) Handle(name string, fn JobFunc) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.handlers[name] = fn
+}
+
+// AddConfig adds a job from a [Job] section. The handler of the
+// job is resolved when the scheduler is started.
+func (s *Scheduler) AddConfig(cfg Config) error {
+	if _, err := ParseSchedule(cfg.Schedule); err != nil {
+		return fmt.Errorf("job %s: %w", cfg.Name, err)
+	}
+	if _, err := cfg.Job(nil); err != nil {
+		return err
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.ctx != nil {
+		return fmt.Errorf("job %s: scheduler already started", cfg.Name)
+	}
+	if s.find(cfg.Name) != nil {
+		return fmt.Errorf("job %s: already added", cfg.Name)
+	}
+	for _, other := range s.configs {
+		if other.Name == cfg.Name {
+			return fmt.Errorf("job %s: already configured", cfg.Name)
+		}
+	}
+	s.configs = append(s.configs, cfg)
+
+	return nil
+}
+
+// Start loads the persisted state and starts executing jobs. It
+// fails if a configured job uses an unknown handler.
+func (s *Scheduler) Start(_ context.Context) error {
+	state, err := loadState(s.statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+	if s.ctx != nil {
+		return errors.New("scheduler already started")
+	}
+
+	// resolve all handlers first so a failed start does not
+	// leave some of the configured jobs behind.
+	configured := make([]*job, 0, len(s.configs))
+	for _, cfg := range s.configs {
+		fn, ok := s.handlers[cfg.handler()]
+		if !ok {
+			return fmt.Errorf("job %s: unknown handler %q", cfg.Name, cfg.handler())
+		}
+
+		j, err := cfg.Job(fn)
+		if err != nil {
+			return err
+		}
+		if j.Location == nil {
+			j.Location = time.Local
+		}
+		sched, _ := ParseSchedule(j.Schedule)
+		configured = append(configured, &job{Job: j, schedule: sched})
+	}
+	s.jobs = append(s.jobs, configured...)
+	s.configs = nil
+	s.state = state
+
+	// jobs must outlive the start context.
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(s.ctx, j)
+	}
+
+	return nil
+}
+
+// Stop stops scheduling new executions, cancels running jobs and
+// waits for them to return or until ctx is cancelled. A stopped
+// scheduler cannot be started again.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.l.Lock()
+	// no new executions may be added to the wait group once
+	// we start waiting for it.
+	s.stopped = true
+	cancel := s.cancel
+	s.l.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run executes the job with the given name immediately. It does
+// not wait for the job to finish. It returns ErrJobNotFound,
+// ErrJobRunning or ErrStopped if the job cannot be executed.
+func (s *Scheduler) Run(name string) error {
+	s.l.Lock()
+	j := s.find(name)
+	ctx := s.ctx
+	s.l.Unlock()
+
+	if j == nil {
+		return fmt.Errorf("job %s: %w", name, ErrJobNotFound)
+	}
+	if ctx == nil {
+		return errors.New("scheduler not started")
+	}
+
+	if err := s.trigger(ctx, j); err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Status returns the status of all jobs.
+func (s *Scheduler) Status() []JobStatus {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	result := make([]JobStatus, len(s.jobs))
+	for idx, j := range s.jobs {
+		result[idx] = JobStatus{
+			State:    s.state[j.Name],
+			Name:     j.Name,
+			Schedule: j.Schedule,
+			Timezone: j.Location.String(),
+			Next:     j.next,
+			Running:  j.running > 0,
+			Runs:     j.runs,
+			Failures: j.failures,
+			Skipped:  j.skipped,
+		}
+	}
+
+	return result
+}
+
+// next returns the next execution time of j after t including
+// jitter.
+func (s *Scheduler) next(j *job, t time.Time) time.Time {
+	next := j.schedule.Next(t.In(j.Location))
+	if j.Jitter > 0 {
+		next = next.Add(time.Duration(s.rand.Int63n(int64(j.Jitter))))
+	}
+	return next
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+
+	s.l.Lock()
+	last := s.state[j.Name].LastRun
+	s.l.Unlock()
+
+	if j.CatchUp && !last.IsZero() && j.schedule.Next(last.In(j.Location)).Before(time.Now()) {
+		s.log.Infof("job %s: catching up on missed run (last run at %s)", j.Name, last)
+		s.trigger(ctx, j)
+	}
+
+	for {
+		s.l.Lock()
+		next := s.next(j, time.Now())
+		j.next = next
+		s.l.Unlock()
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		s.trigger(ctx, j)
+	}
+}
+
+// trigger starts a new execution of j unless the scheduler has
+// been stopped or the job prevents overlapping executions and is
+// already running.
+func (s *Scheduler) trigger(ctx context.Context, j *job) error {
+	s.l.Lock()
+	if s.stopped {
+		s.l.Unlock()
+		return ErrStopped
+	}
+	if j.PreventOverlap && j.running > 0 {
+		j.skipped++
+		s.l.Unlock()
+		s.log.Infof("job %s: still running, skipping execution", j.Name)
+		return ErrJobRunning
+	}
+	j.running++
+	s.wg.Add(1)
+	s.l.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx, j)
+	}()
+
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	s.log.V(5).Logf("job %s: starting", j.Name)
+	err := safeCall(ctx, j.Func)
+	duration := time.Since(start)
+
+	s.l.Lock()
+	j.running--
+	j.runs++
+
+	state := s.state[j.Name]
+	state.LastRun = start
+	state.LastDuration = duration.String()
+	state.LastError = ""
+	if err != nil {
+		j.failures++
+		state.LastError = err.Error()
+	} else {
+		state.LastSuccess = start
+	}
+	s.state[j.Name] = state
+
+	s.version++
+	version := s.version
+	snapshot := make(map[string]State, len(s.state))
+	for name, st := range s.state {
+		snapshot[name] = st
+	}
+	s.l.Unlock()
+
+	saveErr := s.save(version, snapshot)
+
+	if err != nil {
+		s.log.Errorf("job %s: failed after %s: %s", j.Name, duration, err)
+	} else {
+		s.log.V(5).Logf("job %s: finished after %s", j.Name, duration)
+	}
+	if saveErr != nil {
+		s.log.Errorf("failed to save scheduler state: %s", saveErr)
+	}
+}
+
+// save writes snapshot to the state file unless a newer version
+// has already been written. It must not be called while holding
+// s.l.
+func (s *Scheduler) save(version uint64, snapshot map[string]State) error {
+	s.saveL.Lock()
+	defer s.saveL.Unlock()
+
+	if version <= s.saved {
+		return nil
+	}
+	if err := saveState(s.statePath, snapshot); err != nil {
+		return err
+	}
+	s.saved = version
+
+	return nil
+}
+
+// safeCall calls fn and converts panics into errors.
+func safeCall(ctx context.Context, fn JobFunc) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("panic: %v", x)
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"gotest.tools/assert"
+)
+
+func waitFor(t *testing.T, fn func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_ParseSchedule(t *testing.T) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	assert.NilError(t, err)
+
+	s, err := ParseSchedule("0 3 * * *")
+	assert.NilError(t, err)
+	from := time.Date(2021, 3, 27, 12, 0, 0, 0, vienna)
+	assert.Equal(t, time.Date(2021, 3, 28, 3, 0, 0, 0, vienna).Unix(), s.Next(from).Unix())
+
+	s, err = ParseSchedule("15m")
+	assert.NilError(t, err)
+	assert.Equal(t, from.Add(15*time.Minute), s.Next(from))
+
+	s, err = ParseSchedule("@every 1h")
+	assert.NilError(t, err)
+	assert.Equal(t, from.Add(time.Hour), s.Next(from))
+
+	_, err = ParseSchedule("* * *")
+	assert.ErrorContains(t, err, "invalid schedule")
+
+	_, err = ParseSchedule("-1m")
+	assert.ErrorContains(t, err, "invalid interval")
+}
+
+func Test_Jitter(t *testing.T) {
+	s := New(logger.DefaultLogger(), "")
+	sched, err := ParseSchedule("1h")
+	assert.NilError(t, err)
+
+	j := &job{Job: Job{Jitter: time.Minute, Location: time.UTC}, schedule: sched}
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		next := s.next(j, now)
+		assert.Assert(t, !next.Before(now.Add(time.Hour)))
+		assert.Assert(t, next.Before(now.Add(time.Hour+time.Minute)))
+	}
+}
+
+func Test_Scheduler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	s := New(logger.DefaultLogger(), path)
+
+	var runs int32
+	assert.NilError(t, s.Add(Job{
+		Name:     "ok",
+		Schedule: "10ms",
+		Func: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+	}))
+	assert.NilError(t, s.Add(Job{
+		Name:     "fail",
+		Schedule: "10ms",
+		Func: func(ctx context.Context) error {
+			return errors.New("broken")
+		},
+	}))
+	assert.ErrorContains(t, s.Add(Job{Name: "ok", Schedule: "1m", Func: func(context.Context) error { return nil }}), "already added")
+
+	assert.NilError(t, s.Start(context.Background()))
+	waitFor(t, func() bool {
+		for _, st := range s.Status() {
+			if st.Runs < 2 {
+				return false
+			}
+		}
+		return true
+	})
+	assert.NilError(t, s.Stop(context.Background()))
+
+	status := s.Status()
+	assert.Equal(t, "ok", status[0].Name)
+	assert.Equal(t, "", status[0].LastError)
+	assert.Equal(t, uint64(0), status[0].Failures)
+	assert.Equal(t, "broken", status[1].LastError)
+	assert.Equal(t, status[1].Runs, status[1].Failures)
+	assert.Assert(t, status[1].LastSuccess.IsZero())
+
+	state, err := loadState(path)
+	assert.NilError(t, err)
+	assert.Equal(t, "broken", state["fail"].LastError)
+	assert.Assert(t, !state["ok"].LastSuccess.IsZero())
+}
+
+func Test_PreventOverlap(t *testing.T) {
+	s := New(logger.DefaultLogger(), "")
+	block := make(chan struct{})
+
+	assert.NilError(t, s.Add(Job{
+		Name:           "slow",
+		Schedule:       "1h",
+		PreventOverlap: true,
+		Func: func(ctx context.Context) error {
+			select {
+			case <-block:
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	}))
+	assert.NilError(t, s.Start(context.Background()))
+
+	assert.NilError(t, s.Run("slow"))
+	assert.ErrorContains(t, s.Run("slow"), "already running")
+	assert.ErrorContains(t, s.Run("missing"), "not found")
+
+	status := s.Status()
+	assert.Assert(t, status[0].Running)
+	assert.Equal(t, uint64(1), status[0].Skipped)
+
+	close(block)
+	assert.NilError(t, s.Stop(context.Background()))
+}
+
+func Test_CatchUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	assert.NilError(t, saveState(path, map[string]State{
+		"nightly": {LastRun: time.Now().Add(-48 * time.Hour)},
+		"hourly":  {LastRun: time.Now()},
+	}))
+
+	s := New(logger.DefaultLogger(), path)
+	var nightly, hourly int32
+	assert.NilError(t, s.Add(Job{
+		Name:     "nightly",
+		Schedule: "@daily",
+		CatchUp:  true,
+		Func: func(context.Context) error {
+			atomic.AddInt32(&nightly, 1)
+			return nil
+		},
+	}))
+	assert.NilError(t, s.Add(Job{
+		Name:     "hourly",
+		Schedule: "@hourly",
+		CatchUp:  true,
+		Func: func(context.Context) error {
+			atomic.AddInt32(&hourly, 1)
+			return nil
+		},
+	}))
+
+	assert.NilError(t, s.Start(context.Background()))
+	waitFor(t, func() bool { return atomic.LoadInt32(&nightly) == 1 })
+	assert.NilError(t, s.Stop(context.Background()))
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hourly))
+}
+
+func Test_Config(t *testing.T) {
+	s := New(logger.DefaultLogger(), "")
+
+	assert.ErrorContains(t, s.AddConfig(Config{Name: "bad", Schedule: "nope"}), "invalid schedule")
+	assert.ErrorContains(t, s.AddConfig(Config{Name: "bad", Schedule: "1h", Timezone: "Nowhere/City"}), "job bad")
+
+	assert.NilError(t, s.AddConfig(Config{Name: "cleanup", Schedule: "0 3 * * *", Timezone: "Europe/Vienna"}))
+	assert.ErrorContains(t, s.AddConfig(Config{Name: "cleanup", Schedule: "1h"}), "already configured")
+	assert.NilError(t, s.AddConfig(Config{Name: "backup", Handler: "purge", Schedule: "1h"}))
+	s.Handle("purge", func(context.Context) error { return nil })
+	assert.ErrorContains(t, s.Start(context.Background()), `unknown handler "cleanup"`)
+	// a failed start must not add any of the configured jobs.
+	assert.Equal(t, 0, len(s.Status()))
+
+	s = New(logger.DefaultLogger(), "")
+	assert.NilError(t, s.AddConfig(Config{Name: "cleanup", Handler: "purge", Schedule: "0 3 * * *", Timezone: "Europe/Vienna"}))
+	s.Handle("purge", func(context.Context) error { return nil })
+	assert.NilError(t, s.Start(context.Background()))
+
+	waitFor(t, func() bool { return !s.Status()[0].Next.IsZero() })
+	status := s.Status()
+	assert.Equal(t, "Europe/Vienna", status[0].Timezone)
+	// next is calculated in the job location.
+	assert.Equal(t, 3, status[0].Next.Hour())
+
+	assert.NilError(t, s.Stop(context.Background()))
+}
+
+func Test_Empty(t *testing.T) {
+	s := New(logger.DefaultLogger(), "")
+	assert.Assert(t, s.Empty())
+
+	s.Handle("purge", func(context.Context) error { return nil })
+	assert.Assert(t, !s.Empty())
+}
+
+func Test_Stop(t *testing.T) {
+	s := New(logger.DefaultLogger(), "")
+
+	noop := func(context.Context) error { return nil }
+	assert.NilError(t, s.Add(Job{Name: "noop", Schedule: "1h", Func: noop}))
+	assert.NilError(t, s.Start(context.Background()))
+	assert.NilError(t, s.Stop(context.Background()))
+
+	assert.Assert(t, errors.Is(s.Run("noop"), ErrStopped))
+	assert.Assert(t, errors.Is(s.Start(context.Background()), ErrStopped))
+
+	// jobs added after stop are not executed.
+	assert.NilError(t, s.Add(Job{Name: "late", Schedule: "1ms", Func: noop}))
+	assert.NilError(t, s.Stop(context.Background()))
+	assert.Equal(t, uint64(0), s.Status()[1].Runs)
+}
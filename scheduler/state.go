@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFileName is the name of the state file created in the
+// state directory of the service.
+const StateFileName = "scheduler.json"
+
+// State is the persisted state of a job.
+type State struct {
+	// LastRun is the time the last execution started.
+	LastRun time.Time `json:"lastRun"`
+
+	// LastSuccess is the time the last successful execution
+	// started.
+	LastSuccess time.Time `json:"lastSuccess"`
+
+	// LastDuration is the duration of the last execution.
+	LastDuration string `json:"lastDuration,omitempty"`
+
+	// LastError is the error of the last execution, if any.
+	LastError string `json:"lastError,omitempty"`
+}
+
+func loadState(path string) (map[string]State, error) {
+	state := make(map[string]State)
+	if path == "" {
+		return state, nil
+	}
+
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(blob, &state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// saveState atomically replaces the state file at path.
+func saveState(path string, state map[string]State) error {
+	if path == "" {
+		return nil
+	}
+
+	blob, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
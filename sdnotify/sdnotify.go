@@ -0,0 +1,95 @@
+// Package sdnotify implements the systemd service notification
+// protocol (see sd_notify(3)) used by Type=notify units.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well-known notification states.
+const (
+	// Ready tells systemd that the service finished starting up.
+	Ready = "READY=1"
+
+	// Stopping tells systemd that the service is shutting down.
+	Stopping = "STOPPING=1"
+
+	// Reloading tells systemd that the service is reloading its
+	// configuration. Send Ready once the reload is complete.
+	Reloading = "RELOADING=1"
+
+	// Watchdog updates the watchdog timestamp.
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status returns a notification state that sets the free-form
+// status text of the unit.
+func Status(format string, args ...interface{}) string {
+	// STATUS= must not span multiple lines.
+	msg := strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " ")
+	return "STATUS=" + msg
+}
+
+// Enabled returns true if NOTIFY_SOCKET is set.
+func Enabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify sends states to the socket in NOTIFY_SOCKET. It returns
+// false without an error if NOTIFY_SOCKET is not set.
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// abstract sockets are prefixed with @ by systemd.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured by
+// systemd in WATCHDOG_USEC. It returns 0 if the watchdog is
+// disabled or meant for a different process (WATCHDOG_PID).
+// Watchdog notifications should be sent at half the interval.
+func WatchdogInterval() (time.Duration, error) {
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	if usecStr == "" {
+		return 0, nil
+	}
+
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", usecStr)
+	}
+
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid WATCHDOG_PID %q", pidStr)
+		}
+		if pid != os.Getpid() {
+			return 0, nil
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond, nil
+}
@@ -0,0 +1,77 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func Test_Notify(t *testing.T) {
+	setenv(t, "NOTIFY_SOCKET", "")
+	sent, err := Notify(Ready)
+	assert.NilError(t, err)
+	assert.Assert(t, !sent)
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NilError(t, err)
+	defer conn.Close()
+
+	setenv(t, "NOTIFY_SOCKET", path)
+	assert.Assert(t, Enabled())
+
+	sent, err = Notify(Ready, Status("serving\non %d listeners", 2))
+	assert.NilError(t, err)
+	assert.Assert(t, sent)
+
+	buf := make([]byte, 1024)
+	assert.NilError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, "READY=1\nSTATUS=serving on 2 listeners", string(buf[:n]))
+}
+
+func Test_WatchdogInterval(t *testing.T) {
+	setenv(t, "WATCHDOG_USEC", "")
+	setenv(t, "WATCHDOG_PID", "")
+
+	d, err := WatchdogInterval()
+	assert.NilError(t, err)
+	assert.Equal(t, time.Duration(0), d)
+
+	setenv(t, "WATCHDOG_USEC", "30000000")
+	d, err = WatchdogInterval()
+	assert.NilError(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	setenv(t, "WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	d, err = WatchdogInterval()
+	assert.NilError(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	setenv(t, "WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	d, err = WatchdogInterval()
+	assert.NilError(t, err)
+	assert.Equal(t, time.Duration(0), d)
+
+	setenv(t, "WATCHDOG_USEC", "nope")
+	_, err = WatchdogInterval()
+	assert.ErrorContains(t, err, "invalid WATCHDOG_USEC")
+}
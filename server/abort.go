@@ -1,11 +1,8 @@
 package server
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
-	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -14,38 +11,26 @@ import (
 
 // AbortRequest aborts the current request with status and err.
 // If status is 0 it tries to automatically determine the appropriate
-// status code for err.
+// status code for err. The status of an *HTTPError is used as is.
+// Otherwise, errors implementing StatusCode() int or Code() int take
+// precedence over mappings registered using RegisterError and
+// RegisterErrorType which in turn take precedence over any other
+// heuristics. Unless a response has already been written, a RFC 7807
+// problem object describing err is sent to the client.
+// Use WithHideInternalErrors to omit error messages from responses
+// with a 5xx status code.
 func AbortRequest(ctx *gin.Context, status int, err error) {
-	if status == 0 {
-		status = http.StatusInternalServerError
-
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			status = http.StatusNotFound
-		}
-
-		if isErrType(err, &json.SyntaxError{}) || isErrType(err, &strconv.NumError{}) {
-			status = http.StatusBadRequest
-		}
+	mapping, hasMapping := LookupError(err)
 
-		if isErrType(err, &ValidationError{}) {
-			status = http.StatusUnprocessableEntity
-		}
-
-		if e, ok := err.(interface{ StatusCode() int }); ok {
-			status = e.StatusCode()
-		}
-
-		if e, ok := err.(interface{ Code() int }); ok {
-			code := e.Code()
-			// below is a simple plausability check to ensure
-			// Code() was meant to be used for HTTP
-			if code >= 400 && code < 600 {
-				status = code
-			}
-		}
+	if status == 0 {
+		status = statusForError(err, mapping, hasMapping)
 	}
 
-	ctx.AbortWithStatus(status)
+	if ctx.Writer.Written() {
+		ctx.Abort()
+	} else {
+		writeProblem(ctx, newProblem(ctx, status, err, mapping))
+	}
 
 	fields := logger.Fields{
 		"error": err.Error(),
@@ -59,29 +44,34 @@ func AbortRequest(ctx *gin.Context, status int, err error) {
 	logger.From(ctx.Request.Context()).WithFields(fields).Errorf("failed to handle request")
 }
 
-func isErrType(err error, ref error) bool {
-	refType := reflect.Indirect(reflect.ValueOf(ref)).Type()
-	unwrapCause := func(s error) error {
-		e := errors.Unwrap(s)
-		if e == nil {
-			if causer, ok := s.(interface{ Cause() error }); ok {
-				e = causer.Cause()
-			}
-		}
+func statusForError(err error, mapping ErrorMapping, hasMapping bool) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status != 0 {
+		return httpErr.Status
+	}
+
+	status := http.StatusInternalServerError
 
-		return e
+	if strings.Contains(strings.ToLower(err.Error()), "not found") {
+		status = http.StatusNotFound
 	}
 
-	e := err
-	for {
-		t := reflect.Indirect(reflect.ValueOf(e)).Type()
-		if refType == t {
-			return true
-		}
+	if hasMapping && mapping.Status != 0 {
+		status = mapping.Status
+	}
 
-		e = unwrapCause(e)
-		if e == nil {
-			return false
+	if e, ok := err.(interface{ StatusCode() int }); ok {
+		status = e.StatusCode()
+	}
+
+	if e, ok := err.(interface{ Code() int }); ok {
+		code := e.Code()
+		// below is a simple plausability check to ensure
+		// Code() was meant to be used for HTTP
+		if code >= 400 && code < 600 {
+			status = code
 		}
 	}
+
+	return status
 }
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestServer(t *testing.T, handler gin.HandlerFunc, opts ...Option) *Server {
+	opts = append(opts, WithListener(Listener{Address: "127.0.0.1:0"}))
+	srv, err := New("", opts...)
+	assert.NilError(t, err)
+
+	srv.Any("/test/:id", handler)
+
+	return srv
+}
+
+func doRequest(srv http.Handler, req *http.Request) (*httptest.ResponseRecorder, *Problem) {
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		return rec, nil
+	}
+
+	return rec, &p
+}
+
+func Test_AbortRequestProblem(t *testing.T) {
+	srv := newTestServer(t, func(c *gin.Context) {
+		verr := new(ValidationError)
+		verr.AddMissing("name")
+		verr.AddInvalid("mail")
+
+		AbortRequest(c, 0, verr.Build())
+	})
+
+	req := httptest.NewRequest("GET", "/test/1", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+
+	rec, p := doRequest(srv, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Assert(t, p != nil)
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, http.StatusUnprocessableEntity, p.Status)
+	assert.Equal(t, "/test/1", p.Instance)
+	assert.Equal(t, "req-1", p.RequestID)
+	assert.Equal(t, 2, len(p.Errors))
+	assert.Equal(t, "name", p.Errors[0].Field)
+	assert.Equal(t, PropertyMissing, p.Errors[0].Description)
+}
+
+func Test_AbortRequestHideInternalErrors(t *testing.T) {
+	handler := func(c *gin.Context) {
+		AbortRequest(c, 0, errors.New("database exploded"))
+	}
+
+	_, p := doRequest(newTestServer(t, handler), httptest.NewRequest("GET", "/test/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, "database exploded", p.Detail)
+
+	_, p = doRequest(newTestServer(t, handler, WithHideInternalErrors()), httptest.NewRequest("GET", "/test/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, "", p.Detail)
+	assert.Equal(t, "Internal Server Error", p.Title)
+}
+
+type testNotFoundError struct{ ID string }
+
+func (e *testNotFoundError) Error() string { return "record " + e.ID + " missing" }
+
+var errTestConflict = errors.New("entity not found but conflicting")
+
+// testCauseError wraps an error like github.com/pkg/errors.
+type testCauseError struct{ cause error }
+
+func (e *testCauseError) Error() string { return "caused: " + e.cause.Error() }
+func (e *testCauseError) Cause() error  { return e.cause }
+
+type testStatusError struct{}
+
+func (testStatusError) Error() string   { return "slow down" }
+func (testStatusError) StatusCode() int { return http.StatusTooManyRequests }
+
+// restoreErrorRegistry removes all mappings registered during the
+// test.
+func restoreErrorRegistry(t *testing.T) {
+	errRegistryLock.RLock()
+	n := len(errRegistry)
+	errRegistryLock.RUnlock()
+
+	t.Cleanup(func() {
+		errRegistryLock.Lock()
+		defer errRegistryLock.Unlock()
+
+		errRegistry = errRegistry[:n]
+	})
+}
+
+func Test_AbortRequestErrorRegistry(t *testing.T) {
+	restoreErrorRegistry(t)
+
+	RegisterError(errTestConflict, ErrorMapping{Status: http.StatusConflict, Type: "https://example.com/conflict"})
+	RegisterErrorType(&testNotFoundError{}, ErrorMapping{Status: http.StatusNotFound, Title: "Record not found"})
+	RegisterErrorType(testStatusError{}, ErrorMapping{Status: http.StatusConflict})
+
+	cases := []struct {
+		Err    error
+		Status int
+		Type   string
+		Title  string
+		Detail string
+		Code   string
+	}{
+		{fmt.Errorf("wrapped: %w", errTestConflict), http.StatusConflict, "https://example.com/conflict", "Conflict", "wrapped: entity not found but conflicting", ""},
+		{fmt.Errorf("wrapped: %w", &testNotFoundError{"1"}), http.StatusNotFound, "about:blank", "Record not found", "wrapped: record 1 missing", ""},
+		{&json.SyntaxError{}, http.StatusBadRequest, "about:blank", "Bad Request", "", ""},
+		{NewHTTPError(http.StatusForbidden, "NOT_OWNER", "not allowed", errors.New("internal")), http.StatusForbidden, "about:blank", "Forbidden", "not allowed", "NOT_OWNER"},
+		{NewHTTPError(http.StatusServiceUnavailable, "", "try again later", errors.New("internal")), http.StatusServiceUnavailable, "about:blank", "Service Unavailable", "try again later", ""},
+		{errors.New("user not found"), http.StatusNotFound, "about:blank", "Not Found", "user not found", ""},
+		{&testCauseError{&testNotFoundError{"2"}}, http.StatusNotFound, "about:blank", "Record not found", "caused: record 2 missing", ""},
+		// StatusCode takes precedence over the registry.
+		{testStatusError{}, http.StatusTooManyRequests, "about:blank", "Too Many Requests", "slow down", ""},
+	}
+
+	for _, c := range cases {
+		err := c.Err
+		srv := newTestServer(t, func(ctx *gin.Context) {
+			AbortRequest(ctx, 0, err)
+		}, WithHideInternalErrors())
+
+		rec, p := doRequest(srv, httptest.NewRequest("GET", "/test/1", nil))
+		assert.Equal(t, c.Status, rec.Code, c.Err.Error())
+		assert.Equal(t, c.Type, p.Type)
+		assert.Equal(t, c.Title, p.Title)
+		assert.Equal(t, c.Detail, p.Detail)
+		assert.Equal(t, c.Code, p.Code)
+	}
+}
+
+func Test_RegisterErrorTypeNil(t *testing.T) {
+	restoreErrorRegistry(t)
+
+	defer func() {
+		assert.Assert(t, recover() != nil)
+	}()
+
+	RegisterErrorType(nil, ErrorMapping{Status: http.StatusBadRequest})
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// InvalidBodyCode is used as the HTTPError code if a request
+// body cannot be decoded.
+const InvalidBodyCode = "INVALID_BODY"
+
+// BindAndValidate decodes the request body (JSON or form) or the query
+// parameters of ctx into dst and validates the result using the
+// `binding` struct tags supported by gin. Validation failures are
+// returned as a *ValidationError that contains a PropertyError for each
+// failed field, using the JSON path of the field (like items[2].name).
+// Errors that occur while decoding the request are returned as an
+// *HTTPError with status 400. The returned error is meant to be passed
+// to AbortRequest:
+//
+//	var body CreateUserRequest
+//	if err := server.BindAndValidate(ctx, &body); err != nil {
+//	    server.AbortRequest(ctx, 0, err)
+//	    return
+//	}
+//
+// JSON request bodies are cached in ctx (see gin's ShouldBindBodyWith)
+// so they can be bound again by later handlers.
+func BindAndValidate(ctx *gin.Context, dst interface{}) error {
+	b := binding.Default(ctx.Request.Method, ctx.ContentType())
+
+	var err error
+	if b == binding.JSON {
+		// keep the body so we can find the path of values
+		// with an invalid type.
+		err = ctx.ShouldBindBodyWith(dst, binding.JSON)
+	} else {
+		err = ctx.ShouldBindWith(dst, b)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		verr := new(ValidationError)
+		for _, fe := range validationErrors {
+			verr.addCode(fieldPath(reflect.TypeOf(dst), fe.StructNamespace()), codeForTag(fe.Tag()))
+		}
+
+		return verr.Build()
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		path := typeErr.Field
+		// typeErr.Field does not contain array indexes.
+		if body, ok := ctx.Get(gin.BodyBytesKey); ok {
+			if p, ok := jsonPathAt(body.([]byte), typeErr.Offset); ok {
+				path = p
+			}
+		}
+
+		verr := new(ValidationError)
+		verr.AddInvalid(path)
+
+		return verr.Build()
+	}
+
+	return NewHTTPError(http.StatusBadRequest, InvalidBodyCode, "invalid request body", err)
+}
+
+// fieldPath converts the struct namespace of a validation error
+// (like CreateUserRequest.Items[2].Name) into the JSON path of the
+// field (like items[2].name) using the json or form tags of the
+// fields in t. Anonymous struct fields without a tag are flattened
+// like encoding/json does.
+func fieldPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+
+	var path strings.Builder
+	// the first segment is the name of the top-level struct.
+	for _, seg := range segments[1:] {
+		name, index := seg, ""
+		if idx := strings.IndexByte(seg, '['); idx >= 0 {
+			name, index = seg[:idx], seg[idx:]
+		}
+
+		t = indirectType(t)
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				t = f.Type
+				name = fieldName(f)
+			} else {
+				t = nil
+			}
+		}
+
+		if name != "" {
+			if path.Len() > 0 {
+				path.WriteByte('.')
+			}
+			path.WriteString(name)
+		}
+		path.WriteString(index)
+
+		// each index selects an element of a slice, array
+		// or map.
+		for i := strings.Count(index, "["); i > 0 && t != nil; i-- {
+			t = indirectType(t)
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				t = t.Elem()
+			default:
+				t = nil
+			}
+		}
+	}
+
+	return path.String()
+}
+
+// fieldName returns the name of f as used in JSON or form
+// encoded requests.
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return f.Name
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	if f.Anonymous {
+		return ""
+	}
+
+	return f.Name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonPathAt returns the path (like items[2].count) of the JSON
+// value in body that ends at offset. It returns false if there's
+// no such value.
+func jsonPathAt(body []byte, offset int64) (string, bool) {
+	type frame struct {
+		array     bool
+		index     int
+		key       string
+		expectKey bool
+	}
+
+	var stack []*frame
+	top := func() *frame {
+		if len(stack) == 0 {
+			return nil
+		}
+		return stack[len(stack)-1]
+	}
+	// afterValue advances the parent of a value that has been
+	// read completely.
+	afterValue := func() {
+		if f := top(); f != nil {
+			if f.array {
+				f.index++
+			} else {
+				f.expectKey = true
+			}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+
+		if f := top(); f != nil && !f.array && f.expectKey {
+			if tok == json.Delim('}') {
+				stack = stack[:len(stack)-1]
+				afterValue()
+				continue
+			}
+
+			key, _ := tok.(string)
+			f.key = key
+			f.expectKey = false
+			continue
+		}
+
+		if tok == json.Delim(']') {
+			stack = stack[:len(stack)-1]
+			afterValue()
+			continue
+		}
+
+		if dec.InputOffset() >= offset {
+			if len(stack) == 0 {
+				return "", false
+			}
+
+			var path strings.Builder
+			for _, f := range stack {
+				if f.array {
+					fmt.Fprintf(&path, "[%d]", f.index)
+					continue
+				}
+				if path.Len() > 0 {
+					path.WriteByte('.')
+				}
+				path.WriteString(f.key)
+			}
+
+			return path.String(), true
+		}
+
+		switch tok {
+		case json.Delim('['):
+			stack = append(stack, &frame{array: true})
+		case json.Delim('{'):
+			stack = append(stack, &frame{expectKey: true})
+		default:
+			afterValue()
+		}
+	}
+}
+
+// codeForTag returns the property error code for the validation
+// tag that failed.
+func codeForTag(tag string) string {
+	switch {
+	case strings.HasPrefix(tag, "required"):
+		return PropertyMissing
+	case strings.HasPrefix(tag, "excluded"), tag == "isdefault":
+		return PropertyForbidden
+	default:
+		return PropertyInvalid
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gotest.tools/assert"
+)
+
+type bindTestItem struct {
+	Name  string `json:"name" binding:"required"`
+	Count int    `json:"count" binding:"min=1"`
+}
+
+type bindTestRequest struct {
+	Title  string          `json:"title" binding:"required"`
+	Mail   string          `json:"mail" binding:"omitempty,email"`
+	Secret string          `json:"secret" binding:"isdefault"`
+	Items  []*bindTestItem `json:"items" binding:"dive"`
+}
+
+func bindRequest(t *testing.T, body string) (*httptest.ResponseRecorder, *Problem) {
+	srv := newTestServer(t, func(ctx *gin.Context) {
+		var req bindTestRequest
+		if err := BindAndValidate(ctx, &req); err != nil {
+			AbortRequest(ctx, 0, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("POST", "/test/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(srv, req)
+}
+
+func Test_BindAndValidate(t *testing.T) {
+	rec, _ := bindRequest(t, `{"title": "ok", "items": [{"name": "a", "count": 1}]}`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec, p := bindRequest(t, `{"mail": "foo", "secret": "x", "items": [{"name": "a", "count": 1}, {"count": 0}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	fields := make(map[string]string)
+	for _, f := range p.Errors {
+		fields[f.Field] = f.Description
+	}
+	assert.DeepEqual(t, map[string]string{
+		"title":          PropertyMissing,
+		"mail":           PropertyInvalid,
+		"secret":         PropertyForbidden,
+		"items[1].name":  PropertyMissing,
+		"items[1].count": PropertyInvalid,
+	}, fields)
+
+	rec, p = bindRequest(t, `{"title": 1}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "title", p.Errors[0].Field)
+	assert.Equal(t, PropertyInvalid, p.Errors[0].Description)
+
+	// array indexes are reported for values with an invalid type.
+	rec, p = bindRequest(t, ` {"title": "ok", "items": [{"name": "a", "count": 1}, {"name": "b", "count": "x"}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "items[1].count", p.Errors[0].Field)
+
+	rec, p = bindRequest(t, `{"title": `)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, InvalidBodyCode, p.Code)
+}
+
+func Test_BindAndValidateGlobalValidator(t *testing.T) {
+	rec, _ := bindRequest(t, `{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// gin's validator must still report Go field names.
+	err := binding.Validator.ValidateStruct(&bindTestRequest{})
+	var verrs validator.ValidationErrors
+	assert.Assert(t, errors.As(err, &verrs))
+	assert.Equal(t, "bindTestRequest.Title", verrs[0].Namespace())
+}
+
+func Test_JSONPathAt(t *testing.T) {
+	body := []byte(`{"a": {"b": [1, {"c": true}]}, "d": [[1, 2], "x"]}`)
+
+	// the offset of a type error points after literals and
+	// after the first byte of arrays and objects.
+	cases := []struct {
+		Anchor   string
+		Length   int
+		Expected string
+	}{
+		{"[1,", 2, "a.b[0]"},
+		{"true", 4, "a.b[1].c"},
+		{", 2]", 3, "d[0][1]"},
+		{`"x"`, 3, "d[1]"},
+		{"[[", 2, "d[0]"},
+	}
+
+	for _, c := range cases {
+		offset := strings.Index(string(body), c.Anchor) + c.Length
+
+		path, ok := jsonPathAt(body, int64(offset))
+		assert.Assert(t, ok, c.Anchor)
+		assert.Equal(t, c.Expected, path, c.Anchor)
+	}
+
+	_, ok := jsonPathAt([]byte(`1`), 1)
+	assert.Assert(t, !ok)
+}
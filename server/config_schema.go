@@ -1,20 +1,58 @@
 package server
 
 import (
+	"fmt"
+
 	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/service/utils"
 )
 
 // Listener defines a listener for the API server.
 type Listener struct {
+	// Name is an optional name for the listener. It's added to
+	// access log entries and defaults to Address if empty.
+	Name           string
 	Address        string
 	TLSCertFile    string
 	TLSKeyFile     string
 	TrustedProxies []string
+
+	// TrustedNetworks holds the parsed version of TrustedProxies.
+	// It is populated by ParseTrustedProxies which is called by
+	// New for each listener.
+	TrustedNetworks utils.IPNetworks `option:"-"`
+}
+
+// DisplayName returns the name of the listener or its address
+// if no name is configured.
+func (l *Listener) DisplayName() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.Address
+}
+
+// ParseTrustedProxies parses TrustedProxies and stores the result
+// in TrustedNetworks.
+func (l *Listener) ParseTrustedProxies() error {
+	networks, err := utils.ParseNetworks(l.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("listener %s: invalid trusted proxies: %w", l.Address, err)
+	}
+
+	l.TrustedNetworks = networks
+
+	return nil
 }
 
 // ListenerSpec defines the available configuration values for the
 // listener configuration sections.
 var ListenerSpec = conf.SectionSpec{
+	{
+		Name:        "Name",
+		Description: "An optional name for the listener. Defaults to Address.",
+		Type:        conf.StringType,
+	},
 	{
 		Name:        "Address",
 		Required:    true,
@@ -6,4 +6,12 @@ const (
 	// ListenerKey is used to add the Listener configuration
 	// that received a HTTP request to the request context.
 	ListenerKey = contextKey("http:listener")
+
+	// serverKey is used to add the *Server that handles a
+	// HTTP request to the request context.
+	serverKey = contextKey("http:server")
+
+	// connKey is used to add the net.Conn that received a
+	// HTTP request to the request context.
+	connKey = contextKey("http:conn")
 )
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// ErrorMapping describes how an error is reported to HTTP clients.
+type ErrorMapping struct {
+	// Status is the HTTP status code used for the error.
+	Status int
+
+	// Type is the problem type URI reported to clients.
+	// If empty, "about:blank" is used.
+	Type string
+
+	// Title is a short, human-readable summary of the problem
+	// type. If empty, the status text of Status is used.
+	Title string
+}
+
+type errorMatcher struct {
+	match   func(err error) bool
+	mapping ErrorMapping
+}
+
+var (
+	errRegistryLock sync.RWMutex
+	errRegistry     []errorMatcher
+)
+
+// RegisterError registers a mapping for the sentinel error target.
+// AbortRequest uses errors.Is to check if an error matches target.
+// Mappings registered later take precedence over earlier ones.
+func RegisterError(target error, mapping ErrorMapping) {
+	registerMatcher(errorMatcher{
+		match: func(err error) bool {
+			return errors.Is(err, target)
+		},
+		mapping: mapping,
+	})
+}
+
+// RegisterErrorType registers a mapping for all errors that have the
+// same type as ref. AbortRequest uses errors.As to check if an error
+// matches. Note that ref must have the exact type that implements the
+// error interface (i.e. &MyError{} if *MyError implements error).
+// Mappings registered later take precedence over earlier ones.
+func RegisterErrorType(ref error, mapping ErrorMapping) {
+	if ref == nil {
+		panic("server: RegisterErrorType called with a nil error")
+	}
+
+	refType := reflect.TypeOf(ref)
+	registerMatcher(errorMatcher{
+		match: func(err error) bool {
+			target := reflect.New(refType)
+			return errors.As(err, target.Interface())
+		},
+		mapping: mapping,
+	})
+}
+
+func registerMatcher(m errorMatcher) {
+	errRegistryLock.Lock()
+	defer errRegistryLock.Unlock()
+
+	errRegistry = append(errRegistry, m)
+}
+
+// LookupError returns the mapping registered for err. Besides
+// Unwrap, errors that implement Cause() error (like those of
+// github.com/pkg/errors) are unwrapped as well. The second return
+// value reports whether a mapping has been found.
+func LookupError(err error) (ErrorMapping, bool) {
+	errRegistryLock.RLock()
+	defer errRegistryLock.RUnlock()
+
+	for idx := len(errRegistry) - 1; idx >= 0; idx-- {
+		for e := err; e != nil; e = unwrapCause(e) {
+			if errRegistry[idx].match(e) {
+				return errRegistry[idx].mapping, true
+			}
+		}
+	}
+
+	return ErrorMapping{}, false
+}
+
+// unwrapCause returns the error wrapped by err using either
+// Unwrap or Cause.
+func unwrapCause(err error) error {
+	if e := errors.Unwrap(err); e != nil {
+		return e
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		return causer.Cause()
+	}
+
+	return nil
+}
+
+func init() {
+	RegisterErrorType(&json.SyntaxError{}, ErrorMapping{Status: 400})
+	RegisterErrorType(&json.UnmarshalTypeError{}, ErrorMapping{Status: 400})
+	RegisterErrorType(&strconv.NumError{}, ErrorMapping{Status: 400})
+	RegisterErrorType(&ValidationError{}, ErrorMapping{Status: 422})
+}
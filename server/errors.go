@@ -31,6 +31,15 @@ type (
 
 		// Description is the description of the error
 		Description string `json:"message"`
+
+		// Code is the stable error code of the property error
+		// (like PropertyMissing). It is set by AddMissing,
+		// AddTaken, AddInvalid and AddForbidden.
+		Code string `json:"code,omitempty"`
+
+		// LocalizedMessage holds a translated, human readable
+		// message for the error. It is set by Localize.
+		LocalizedMessage string `json:"localizedMessage,omitempty"`
 	}
 
 	// ValidationError is a common request body validation error
@@ -53,6 +62,41 @@ func (v *ValidationError) Add(field, message string) {
 	})
 }
 
+// addCode adds a new property validation error with code to v.
+func (v *ValidationError) addCode(field, code string) {
+	v.Fields = append(v.Fields, &PropertyError{
+		Field:       field,
+		Description: code,
+		Code:        code,
+	})
+}
+
+// Localize returns a copy of v with the LocalizedMessage of each
+// property error set to the text for it's code in lang. If the
+// property error does not have a code the description is used as
+// the catalog key.
+func (v *ValidationError) Localize(cat *Catalog, lang string) *ValidationError {
+	result := &ValidationError{
+		Fields: make([]*PropertyError, len(v.Fields)),
+	}
+
+	for idx, field := range v.Fields {
+		localized := *field
+		key := localized.Code
+		if key == "" {
+			key = localized.Description
+		}
+
+		if text, ok := cat.Lookup(lang, key); ok {
+			localized.LocalizedMessage = text
+		}
+
+		result.Fields[idx] = &localized
+	}
+
+	return result
+}
+
 // Error implements the error interface
 func (v *ValidationError) Error() string {
 	s := make([]string, len(v.Fields))
@@ -75,20 +119,72 @@ func (v *ValidationError) Build() error {
 
 // AddMissing adds a PropertyMissing error for p
 func (v *ValidationError) AddMissing(p string) {
-	v.Add(p, PropertyMissing)
+	v.addCode(p, PropertyMissing)
 }
 
 // AddTaken adds a PropertyTaken error for p
 func (v *ValidationError) AddTaken(p string) {
-	v.Add(p, PropertyTaken)
+	v.addCode(p, PropertyTaken)
 }
 
 // AddInvalid adds a PropertyInvalid error for p
 func (v *ValidationError) AddInvalid(p string) {
-	v.Add(p, PropertyInvalid)
+	v.addCode(p, PropertyInvalid)
 }
 
 // AddForbidden adds a PropertyForbidden error for p
 func (v *ValidationError) AddForbidden(p string) {
-	v.Add(p, PropertyForbidden)
+	v.addCode(p, PropertyForbidden)
+}
+
+// HTTPError is an error that carries the HTTP status code and a message
+// that is safe to be sent to clients.
+type HTTPError struct {
+	// Status is the HTTP status code for the error.
+	Status int
+
+	// Code is a stable, machine readable error code that
+	// is sent to clients.
+	Code string
+
+	// Message is a human readable message that is sent to
+	// clients even if internal errors are hidden.
+	Message string
+
+	// Err is the underlying error, if any. It is never sent
+	// to clients.
+	Err error
+}
+
+// NewHTTPError returns a new HTTPError.
+func NewHTTPError(status int, code, message string, err error) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", e.Message, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code of e.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
 }
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog maps message keys to human readable texts in different
+// languages. Keys are property codes (like PropertyMissing), HTTPError
+// codes, problem types of registered error mappings and status keys
+// as returned by StatusKey.
+type Catalog struct {
+	rw       sync.RWMutex
+	fallback string
+	messages map[string]map[string]string
+}
+
+// NewCatalog returns a new, empty message catalog. fallback is the
+// language that is used if no language requested by the client is
+// supported.
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: strings.ToLower(fallback),
+		messages: make(map[string]map[string]string),
+	}
+}
+
+// Set sets the text for key in lang.
+func (cat *Catalog) Set(lang, key, text string) {
+	lang = strings.ToLower(lang)
+
+	cat.rw.Lock()
+	defer cat.rw.Unlock()
+
+	if cat.messages[lang] == nil {
+		cat.messages[lang] = make(map[string]string)
+	}
+	cat.messages[lang][key] = text
+}
+
+// SetAll sets all texts from messages for lang.
+func (cat *Catalog) SetAll(lang string, messages map[string]string) {
+	for key, text := range messages {
+		cat.Set(lang, key, text)
+	}
+}
+
+// Lookup returns the text for key in lang. If there's no text for
+// lang the fallback language of cat is used. The second return value
+// reports whether a text has been found.
+func (cat *Catalog) Lookup(lang, key string) (string, bool) {
+	cat.rw.RLock()
+	defer cat.rw.RUnlock()
+
+	if text, ok := cat.messages[strings.ToLower(lang)][key]; ok {
+		return text, true
+	}
+
+	text, ok := cat.messages[cat.fallback][key]
+	return text, ok
+}
+
+// Languages returns all languages supported by cat.
+func (cat *Catalog) Languages() []string {
+	cat.rw.RLock()
+	defer cat.rw.RUnlock()
+
+	langs := make([]string, 0, len(cat.messages))
+	for lang := range cat.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	return langs
+}
+
+// Negotiate returns the best language supported by cat for the value
+// of an Accept-Language header. If no language matches the fallback
+// language of cat is returned.
+func (cat *Catalog) Negotiate(acceptLanguage string) string {
+	cat.rw.RLock()
+	defer cat.rw.RUnlock()
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if tag == "*" {
+			break
+		}
+
+		if _, ok := cat.messages[tag]; ok {
+			return tag
+		}
+
+		// try the primary language sub-tag (de-AT -> de)
+		if idx := strings.Index(tag, "-"); idx > 0 {
+			if _, ok := cat.messages[tag[:idx]]; ok {
+				return tag[:idx]
+			}
+		}
+	}
+
+	return cat.fallback
+}
+
+// parseAcceptLanguage returns all language tags of header ordered
+// by their quality value. Tags with a quality of 0 are omitted.
+func parseAcceptLanguage(header string) []string {
+	type langQ struct {
+		tag string
+		q   float64
+	}
+
+	var tags []langQ
+	for _, part := range strings.Split(header, ",") {
+		values := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(values[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range values[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		if q > 0 {
+			tags = append(tags, langQ{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	result := make([]string, len(tags))
+	for idx, t := range tags {
+		result[idx] = t.tag
+	}
+
+	return result
+}
+
+// StatusKey returns the catalog key used for the title of problems
+// with the given HTTP status code.
+func StatusKey(status int) string {
+	return "status:" + strconv.Itoa(status)
+}
+
+// DefaultCatalog is the message catalog used by AbortRequest if
+// no other catalog has been configured using WithCatalog. It
+// contains English and German texts for all property codes and
+// common HTTP status codes.
+var DefaultCatalog = NewCatalog("en")
+
+func init() {
+	DefaultCatalog.SetAll("en", map[string]string{
+		PropertyMissing:   "This field is required.",
+		PropertyForbidden: "This field must not be set.",
+		PropertyTaken:     "This value is already taken.",
+		PropertyInvalid:   "This value is invalid.",
+		InvalidBodyCode:   "The request body is invalid.",
+	})
+
+	DefaultCatalog.SetAll("de", map[string]string{
+		PropertyMissing:   "Dieses Feld ist erforderlich.",
+		PropertyForbidden: "Dieses Feld darf nicht angegeben werden.",
+		PropertyTaken:     "Dieser Wert ist bereits vergeben.",
+		PropertyInvalid:   "Dieser Wert ist ungültig.",
+		InvalidBodyCode:   "Der Inhalt der Anfrage ist ungültig.",
+
+		StatusKey(400): "Ungültige Anfrage",
+		StatusKey(401): "Nicht angemeldet",
+		StatusKey(403): "Zugriff verweigert",
+		StatusKey(404): "Nicht gefunden",
+		StatusKey(405): "Methode nicht erlaubt",
+		StatusKey(409): "Konflikt",
+		StatusKey(413): "Anfrage zu groß",
+		StatusKey(415): "Nicht unterstützter Inhaltstyp",
+		StatusKey(422): "Ungültige Eingabe",
+		StatusKey(429): "Zu viele Anfragen",
+		StatusKey(500): "Interner Serverfehler",
+		StatusKey(502): "Fehlerhaftes Gateway",
+		StatusKey(503): "Dienst nicht verfügbar",
+		StatusKey(504): "Gateway-Zeitüberschreitung",
+	})
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/assert"
+)
+
+func Test_CatalogNegotiate(t *testing.T) {
+	cat := NewCatalog("en")
+	cat.Set("en", "key", "text")
+	cat.Set("de", "key", "Text")
+
+	cases := []struct {
+		I string
+		O string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-AT,de;q=0.9,en;q=0.8", "de"},
+		{"fr, en;q=0.5, de;q=0.8", "de"},
+		{"de;q=0, en", "en"},
+		{"fr", "en"},
+		{"*", "en"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.O, cat.Negotiate(c.I), c.I)
+	}
+
+	text, ok := cat.Lookup("fr", "key")
+	assert.Assert(t, ok)
+	assert.Equal(t, "text", text)
+}
+
+func Test_AbortRequestLocalized(t *testing.T) {
+	srv := newTestServer(t, func(ctx *gin.Context) {
+		verr := new(ValidationError)
+		verr.AddMissing("name")
+		verr.Add("mail", "must be a company address")
+
+		AbortRequest(ctx, 0, verr.Build())
+	})
+
+	req := httptest.NewRequest("GET", "/test/1", nil)
+	req.Header.Set("Accept-Language", "de-AT, en;q=0.5")
+
+	rec, p := doRequest(srv, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "de", rec.Header().Get("Content-Language"))
+	assert.Equal(t, "Ungültige Eingabe", p.Title)
+	assert.Equal(t, PropertyMissing, p.Errors[0].Code)
+	assert.Equal(t, PropertyMissing, p.Errors[0].Description)
+	assert.Equal(t, "Dieses Feld ist erforderlich.", p.Errors[0].LocalizedMessage)
+	assert.Equal(t, "", p.Errors[1].Code)
+	assert.Equal(t, "", p.Errors[1].LocalizedMessage)
+}
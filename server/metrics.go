@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+)
+
+// Metrics holds the Prometheus metrics of the built-in HTTP
+// server. See WithMetrics.
+type Metrics struct {
+	requests    *prometheus.CounterVec
+	latency     *prometheus.HistogramVec
+	inFlight    prometheus.Gauge
+	connections *prometheus.GaugeVec
+	accepted    *prometheus.CounterVec
+}
+
+// NewMetrics creates all HTTP server metrics and registers them at
+// reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests by route, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being handled.",
+		}),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_listener_connections",
+			Help: "Number of open connections by listener.",
+		}, []string{"listener"}),
+		accepted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_listener_connections_total",
+			Help: "Total number of accepted connections by listener.",
+		}, []string{"listener"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.latency, m.inFlight, m.connections, m.accepted} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Observe implements accesslog.Observer and records the request
+// count and latency.
+func (m *Metrics) Observe(c *gin.Context, latency time.Duration, _ logger.Fields) {
+	route := c.FullPath()
+	if route == "" {
+		route = accesslog.UnmatchedRoute
+	}
+	status := strconv.Itoa(c.Writer.Status())
+
+	m.requests.WithLabelValues(route, c.Request.Method, status).Inc()
+	m.latency.WithLabelValues(route, c.Request.Method, status).Observe(latency.Seconds())
+}
+
+// trackInFlight is a gin middleware that tracks the number of
+// in-flight requests.
+func (m *Metrics) trackInFlight(c *gin.Context) {
+	m.inFlight.Inc()
+	defer m.inFlight.Dec()
+
+	c.Next()
+}
+
+// connState returns a http.Server.ConnState callback that tracks
+// the connections of listener.
+func (m *Metrics) connState(listener string) func(net.Conn, http.ConnState) {
+	open := m.connections.WithLabelValues(listener)
+	accepted := m.accepted.WithLabelValues(listener)
+
+	return func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Inc()
+			accepted.Inc()
+		case http.StateHijacked, http.StateClosed:
+			open.Dec()
+		}
+	}
+}
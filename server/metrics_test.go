@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func Test_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	srv := newTestServer(t, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	}, WithMetrics(reg))
+
+	for i := 0; i < 2; i++ {
+		doRequest(srv, httptest.NewRequest("GET", "/test/1", nil))
+	}
+	doRequest(srv, httptest.NewRequest("GET", "/unknown", nil))
+
+	expected := `
+# HELP http_requests_total Total number of HTTP requests by route, method and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/test/:id",status="204"} 2
+http_requests_total{method="GET",route="<unmatched>",status="404"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "http_requests_total")
+	assert.NilError(t, err)
+
+	count, err := testutil.GatherAndCount(reg, "http_request_duration_seconds")
+	assert.NilError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP http_requests_in_flight Number of HTTP requests currently being handled.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight 0
+`), "http_requests_in_flight")
+	assert.NilError(t, err)
+}
@@ -1,6 +1,11 @@
 package server
 
-import "github.com/tierklinik-dobersberg/logger"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+	"go.opentelemetry.io/otel/trace"
+)
 
 // Option can be passed to a server. When called only the
 // *gin.Engine of the server is ensured to be set.
@@ -31,3 +36,56 @@ func WithListener(l ...Listener) Option {
 		return nil
 	}
 }
+
+// WithHideInternalErrors configures the server to not include
+// error messages in problem responses with a 5xx status code
+// sent by AbortRequest.
+func WithHideInternalErrors() Option {
+	return func(s *Server) error {
+		s.hideInternalErrors = true
+		return nil
+	}
+}
+
+// WithCatalog configures the message catalog that is used
+// to localize problem responses sent by AbortRequest.
+// Defaults to DefaultCatalog.
+func WithCatalog(cat *Catalog) Option {
+	return func(s *Server) error {
+		s.catalog = cat
+		return nil
+	}
+}
+
+// WithAccessLog configures the access log of the server.
+// If cfg.Path is empty the access log path passed to New
+// is used.
+func WithAccessLog(cfg accesslog.Config) Option {
+	return func(s *Server) error {
+		s.accessLogCfg = cfg
+		return nil
+	}
+}
+
+// WithTracerProvider configures the tracer provider used to
+// create spans for incoming requests. Defaults to the global
+// tracer provider. See TracingMiddleware.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Server) error {
+		s.tracerProvider = tp
+		return nil
+	}
+}
+
+// WithMetrics enables Prometheus metrics for HTTP requests and
+// listener connections and registers them at reg. See Metrics.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(s *Server) error {
+		m, err := NewMetrics(reg)
+		if err != nil {
+			return err
+		}
+		s.metrics = m
+		return nil
+	}
+}
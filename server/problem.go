@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the content type used for problem
+// responses as defined in RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem is a problem details object as defined in RFC 7807.
+// It is sent to clients by AbortRequest.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	// It defaults to "about:blank".
+	Type string `json:"type"`
+
+	// Title is a short, human-readable summary of the problem
+	// type.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Detail is a human-readable explanation specific to this
+	// occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance identifies the specific occurrence of the problem.
+	// AbortRequest sets it to the request path.
+	Instance string `json:"instance,omitempty"`
+
+	// Code is a stable, machine readable error code. It is
+	// set if the problem has been caused by an *HTTPError.
+	Code string `json:"code,omitempty"`
+
+	// RequestID holds the ID of the request that caused the problem,
+	// if any.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Errors holds all property errors if the problem has been
+	// caused by a *ValidationError.
+	Errors []*PropertyError `json:"errors,omitempty"`
+}
+
+// newProblem creates a new problem object for err that occurred
+// while handling the request in ctx. mapping holds the error mapping
+// registered for err, if any. Titles, HTTPError messages and property
+// errors are localized using the language negotiated from the
+// Accept-Language header of the request.
+func newProblem(ctx *gin.Context, status int, err error, mapping ErrorMapping) *Problem {
+	srv := serverFromContext(ctx)
+
+	cat := DefaultCatalog
+	if srv != nil {
+		cat = srv.catalog
+	}
+	lang := cat.Negotiate(ctx.GetHeader("Accept-Language"))
+	ctx.Header("Content-Language", lang)
+
+	p := &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  ctx.Request.URL.Path,
+		RequestID: RequestIDFromContext(ctx),
+	}
+
+	if text, ok := cat.Lookup(lang, StatusKey(status)); ok {
+		p.Title = text
+	}
+
+	if mapping.Type != "" {
+		p.Type = mapping.Type
+
+		if text, ok := cat.Lookup(lang, mapping.Type); ok {
+			p.Title = text
+		}
+	}
+	if mapping.Title != "" {
+		p.Title = mapping.Title
+	}
+
+	// HTTPErrors carry a public message that is always safe to
+	// be sent to clients.
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		p.Code = httpErr.Code
+		p.Detail = httpErr.Message
+
+		if text, ok := cat.Lookup(lang, httpErr.Code); ok && httpErr.Code != "" {
+			p.Detail = text
+		}
+
+		return p
+	}
+
+	if status >= 500 && srv != nil && srv.hideInternalErrors {
+		return p
+	}
+
+	p.Detail = err.Error()
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		p.Errors = verr.Localize(cat, lang).Fields
+	}
+
+	return p
+}
+
+// writeProblem sends p to the client.
+func writeProblem(ctx *gin.Context, p *Problem) {
+	blob, err := json.Marshal(p)
+	if err != nil {
+		ctx.AbortWithStatus(p.Status)
+		return
+	}
+
+	ctx.Abort()
+	ctx.Data(p.Status, ProblemContentType, blob)
+}
+
+// serverFromContext returns the *Server that handles the request
+// in ctx. It returns nil if the request is not handled by a
+// *Server.
+func serverFromContext(ctx *gin.Context) *Server {
+	srv, _ := ctx.Request.Context().Value(serverKey).(*Server)
+	return srv
+}
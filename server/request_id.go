@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+)
+
+// RequestIDHeader is the HTTP header used to accept, return and
+// forward request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of request IDs accepted
+// from clients.
+const maxRequestIDLength = 128
+
+// requestIDKey is used to add the request ID to the request
+// context.
+const requestIDKey = contextKey("http:request-id")
+
+// RequestIDMiddleware returns a gin middleware that accepts the
+// request ID sent by the client in RequestIDHeader or generates a
+// new one. The request ID is added to the request context, the
+// context logger (as logging.RequestIDField), the gin.Context and
+// the response headers. It is installed by New before the access
+// logger.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey, id)
+		// logger.WithFields and logger.With share the same context
+		// key so we need to store a new logger instead of fields.
+		ctx = logger.With(ctx, logger.From(ctx).WithFields(logger.Fields{
+			logging.RequestIDField: id,
+		}))
+		c.Request = c.Request.WithContext(ctx)
+
+		// public gin keys are added to the access log.
+		c.Set(logging.RequestIDField, id)
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the request ID associated with ctx.
+// ctx may also be a *gin.Context.
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		if c.Request == nil {
+			return ""
+		}
+		ctx = c.Request.Context()
+	}
+
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// RequestIDTransport is a http.RoundTripper that forwards the
+// request ID of the request context in RequestIDHeader. Use it for
+// outgoing requests made while handling an incoming request.
+type RequestIDTransport struct {
+	// Base is the http.RoundTripper used to send requests.
+	// It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if id := RequestIDFromContext(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	return base.RoundTrip(req)
+}
+
+// validRequestID returns true if id is a non-empty request ID that
+// only contains safe characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z',
+			r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.', r == ':', r == '+', r == '=', r == '/':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns a new random request ID.
+func newRequestID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		// crypto/rand never fails on supported platforms.
+		panic(err)
+	}
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"gotest.tools/assert"
+)
+
+func Test_RequestIDMiddleware(t *testing.T) {
+	var (
+		fromContext string
+		logFields   logger.Fields
+	)
+	srv := newTestServer(t, func(c *gin.Context) {
+		fromContext = RequestIDFromContext(c)
+
+		logger.From(c.Request.Context()).Info("handled")
+		c.Status(http.StatusNoContent)
+	}, WithLogger(logger.New(logger.AdapterFunc(func(_ time.Time, _ logger.Severity, _ string, f logger.Fields) {
+		logFields = f
+	}))))
+
+	// a valid request ID is accepted
+	req := httptest.NewRequest("GET", "/test/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec, _ := doRequest(srv, req)
+	assert.Equal(t, "req-1", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-1", fromContext)
+	assert.Equal(t, "req-1", logFields[logging.RequestIDField])
+
+	// invalid request IDs are replaced
+	for _, id := range []string{"", "invalid id", strings.Repeat("a", maxRequestIDLength+1)} {
+		req = httptest.NewRequest("GET", "/test/1", nil)
+		req.Header.Set(RequestIDHeader, id)
+		rec, _ = doRequest(srv, req)
+
+		generated := rec.Header().Get(RequestIDHeader)
+		assert.Equal(t, 32, len(generated))
+		assert.Equal(t, generated, fromContext)
+	}
+}
+
+func Test_RequestIDTransport(t *testing.T) {
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(RequestIDHeader)
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: new(RequestIDTransport)}
+
+	ctx := context.WithValue(context.Background(), requestIDKey, "req-1")
+	req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+	assert.NilError(t, err)
+
+	res, err := client.Do(req)
+	assert.NilError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, "req-1", received)
+	assert.Equal(t, "", req.Header.Get(RequestIDHeader))
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireListener returns a middleware that only allows requests
+// accepted by one of the listeners in names. Listeners are matched
+// by their DisplayName. Requests received on any other listener are
+// answered with 404 Not Found so the existence of the route is not
+// disclosed. If names is empty, all requests are allowed.
+func RequireListener(names ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		allowed[n] = struct{}{}
+	}
+
+	return func(ctx *gin.Context) {
+		if len(allowed) == 0 {
+			return
+		}
+
+		if _, ok := allowed[listenerName(ctx.Request)]; !ok {
+			ctx.AbortWithStatus(http.StatusNotFound)
+		}
+	}
+}
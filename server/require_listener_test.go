@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/assert"
+)
+
+func Test_RequireListener(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/admin", RequireListener("admin"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func(l *Listener) int {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		if l != nil {
+			req = req.WithContext(context.WithValue(req.Context(), ListenerKey, l))
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, send(&Listener{Name: "admin", Address: "127.0.0.1:9000"}))
+	assert.Equal(t, http.StatusNoContent, send(&Listener{Address: "admin"}))
+	assert.Equal(t, http.StatusNotFound, send(&Listener{Name: "public", Address: "0.0.0.0:80"}))
+	assert.Equal(t, http.StatusNotFound, send(nil))
+}
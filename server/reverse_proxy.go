@@ -10,15 +10,27 @@ import (
 // WithTrustedProxyHeaders checks if the direct client (RemoteAddr field of req) is a trusted
 // reverse proxy and if, extracts data from headers like X-Forwarded-For, ... and adds them
 // to the request context.
-// TODO(ppacher): should we allow to configure which headers are trusted? Or is it safe to
-// assume that X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Host, X-Real-IP and the
-// official Forwarded are fine to trust?
+//
+// Deprecated: WithTrustedProxyHeaders parses proxies on each call. Use
+// WithTrustedNetworks with pre-parsed networks instead.
 func WithTrustedProxyHeaders(proxies []string, req *http.Request) *http.Request {
-	log := logger.From(req.Context())
-
 	networks, err := utils.ParseNetworks(proxies)
 	if err != nil {
-		log.Errorf("failed to parse proxies: %s", err)
+		logger.From(req.Context()).Errorf("failed to parse proxies: %s", err)
+		return req
+	}
+
+	return WithTrustedNetworks(networks, req)
+}
+
+// WithTrustedNetworks is like WithTrustedProxyHeaders but expects the trusted
+// proxy networks to be parsed already. It is called for each request so
+// it must not perform any expensive operations.
+// TODO(ppacher): should we allow to configure which headers are trusted? Or is it safe to
+// assume that X-Forwarded-For, X-Forwarded-Proto, X-Forwarded-Host, X-Real-IP and the
+// official Forwarded are fine to trust?
+func WithTrustedNetworks(networks utils.IPNetworks, req *http.Request) *http.Request {
+	if len(networks) == 0 {
 		return req
 	}
 
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tierklinik-dobersberg/service/utils"
+)
+
+var benchProxies = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fd00::/8"}
+
+func BenchmarkWithTrustedProxyHeaders(b *testing.B) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.1.1:4312"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WithTrustedProxyHeaders(benchProxies, req)
+	}
+}
+
+func BenchmarkWithTrustedNetworks(b *testing.B) {
+	networks, err := utils.ParseNetworks(benchProxies)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.1.1:4312"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WithTrustedNetworks(networks, req)
+	}
+}
+
+func BenchmarkWithTrustedNetworksUntrusted(b *testing.B) {
+	networks, err := utils.ParseNetworks(benchProxies)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "8.8.8.8:4312"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WithTrustedNetworks(networks, req)
+	}
+}
@@ -6,13 +6,36 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/ory/graceful"
 	"golang.org/x/sync/errgroup"
 )
 
+// withDefaults is replaced during tests.
+var withDefaults = graceful.WithDefaults
+
+// SetWriteDeadline sets the write deadline of the connection that
+// received r. Long running responses like event streams use it to
+// extend the WriteTimeout of the listener before writing. A zero
+// value for t disables the deadline. It fails if r has not been
+// received by a listener of Run.
+func SetWriteDeadline(r *http.Request, t time.Time) error {
+	conn, ok := r.Context().Value(connKey).(net.Conn)
+	if !ok {
+		return errors.New("request has no connection")
+	}
+
+	return conn.SetWriteDeadline(t)
+}
+
+// accessLogCloseTimeout is the time granted to flush the access log
+// if the shutdown context has already expired.
+const accessLogCloseTimeout = 5 * time.Second
+
 // Shutdown stops all HTTP server listeners and waits for them to
-// close. If ctx is cancelled Shutdown returns immediately. See
+// close or until ctx is cancelled. Afterwards, any buffered access
+// log messages are flushed, even if ctx has already expired. See
 // http.Server.Shutdown for more information.
 func (srv *Server) Shutdown(ctx context.Context) error {
 	errGrp := new(errgroup.Group)
@@ -29,15 +52,58 @@ func (srv *Server) Shutdown(ctx context.Context) error {
 		ch <- errGrp.Wait()
 	}()
 
+	var err error
 	select {
-	case err := <-ch:
-		return err
+	case err = <-ch:
 	case <-ctx.Done():
-		return ctx.Err()
+		err = ctx.Err()
+	}
+
+	if closeErr := srv.closeAccessLog(ctx); err == nil {
+		err = closeErr
 	}
+
+	return err
+}
+
+// closeAccessLog closes the access log writer once and reports the
+// number of dropped log messages. Requests that are still running
+// cannot write to the access log afterwards.
+func (srv *Server) closeAccessLog(ctx context.Context) error {
+	if srv.accessLogWriter == nil {
+		return nil
+	}
+
+	srv.accessLogClose.Do(func() {
+		if ctx.Err() != nil {
+			// queued messages would be lost otherwise.
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), accessLogCloseTimeout)
+			defer cancel()
+		}
+
+		if err := srv.accessLogWriter.Close(ctx); err != nil {
+			srv.logger.Errorf("failed to close access log: %s", err)
+			srv.accessLogCloseErr = err
+		}
+
+		if dropped := srv.accessLogWriter.Dropped(); dropped > 0 {
+			srv.logger.Errorf("%d access log messages have been dropped", dropped)
+		}
+	})
+
+	return srv.accessLogCloseErr
+}
+
+// Ready returns a channel that is closed once Run is listening on
+// all configured listeners.
+func (srv *Server) Ready() <-chan struct{} {
+	return srv.ready
 }
 
 // Run starts listening and serving on all configured listeners.
+// All listeners are bound before serving starts so Run fails
+// early if any of them is unavailable. See Ready.
 func (srv *Server) Run() error {
 	if len(srv.listenCfgs) == 0 {
 		return fmt.Errorf("no listeners configured")
@@ -52,38 +118,69 @@ func (srv *Server) Run() error {
 		var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
 			// extract trusted proxy headers like X-Forwarded-For, X-Real-IP,
 			// X-Forwarded-Proto, ...
-			r = WithTrustedProxyHeaders(listener.TrustedProxies, r)
+			r = WithTrustedNetworks(listener.TrustedNetworks, r)
 
 			// actually call the servers handler
 			srv.ServeHTTP(w, r)
 		}
 
-		s := graceful.WithDefaults(&http.Server{
+		s := withDefaults(&http.Server{
 			Handler: fn,
 			Addr:    cfg.Address,
 			ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+				ctx = context.WithValue(ctx, connKey, c)
 				return context.WithValue(ctx, ListenerKey, &listener)
 			},
 		})
+		if srv.metrics != nil {
+			s.ConnState = srv.metrics.connState(listener.DisplayName())
+		}
 
 		srv.servers[idx] = s
 	}
 
+	listeners := make([]net.Listener, len(srv.servers))
+	for idx, s := range srv.servers {
+		addr := s.Addr
+		if addr == "" {
+			// use the same defaults as http.Server.ListenAndServe
+			// and ListenAndServeTLS.
+			addr = ":http"
+			if srv.listenCfgs[idx].TLSCertFile != "" {
+				addr = ":https"
+			}
+		}
+
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners[:idx] {
+				l.Close()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners[idx] = l
+	}
+
 	errGrp := new(errgroup.Group)
 
 	for idx := range srv.servers {
 		s := srv.servers[idx]
+		l := listeners[idx]
 		cfg := srv.listenCfgs[idx]
 
 		if cfg.TLSCertFile != "" {
 			errGrp.Go(func() error {
-				return s.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+				return s.ServeTLS(l, cfg.TLSCertFile, cfg.TLSKeyFile)
 			})
 		} else {
-			errGrp.Go(s.ListenAndServe)
+			errGrp.Go(func() error {
+				return s.Serve(l)
+			})
 		}
 	}
 
+	close(srv.ready)
+
 	ch := make(chan error, 1)
 
 	go func() {
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+	"gotest.tools/assert"
+)
+
+func Test_RunReady(t *testing.T) {
+	srv := newTestServer(t, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run()
+	}()
+
+	select {
+	case <-srv.Ready():
+	case err := <-errCh:
+		t.Fatalf("run failed: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not become ready")
+	}
+
+	assert.NilError(t, srv.Shutdown(context.Background()))
+	err := <-errCh
+	assert.Assert(t, errors.Is(err, http.ErrServerClosed))
+}
+
+func Test_RunListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer l.Close()
+
+	srv, err := New("", WithListener(
+		Listener{Address: "127.0.0.1:0"},
+		Listener{Address: l.Addr().String()},
+	))
+	assert.NilError(t, err)
+
+	assert.ErrorContains(t, srv.Run(), "failed to listen on "+l.Addr().String())
+
+	select {
+	case <-srv.Ready():
+		t.Fatal("server must not be ready")
+	default:
+	}
+}
+
+func Test_SetWriteDeadline(t *testing.T) {
+	defer func(old func(*http.Server) *http.Server) {
+		withDefaults = old
+	}(withDefaults)
+	withDefaults = func(s *http.Server) *http.Server {
+		s.WriteTimeout = 100 * time.Millisecond
+		return s
+	}
+
+	// reserve a free port for the server.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	addr := l.Addr().String()
+	assert.NilError(t, l.Close())
+
+	srv, err := New("", WithListener(Listener{Address: addr}))
+	assert.NilError(t, err)
+	srv.GET("/stream", func(c *gin.Context) {
+		for _, msg := range []string{"first\n", "second\n"} {
+			if err := SetWriteDeadline(c.Request, time.Now().Add(time.Second)); err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			if _, err := c.Writer.WriteString(msg); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+			// outlive the WriteTimeout of the server.
+			time.Sleep(300 * time.Millisecond)
+		}
+	})
+
+	go srv.Run()
+	defer srv.Shutdown(context.Background())
+	<-srv.Ready()
+
+	res, err := http.Get("http://" + addr + "/stream")
+	assert.NilError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(body))
+
+	assert.ErrorContains(t, SetWriteDeadline(httptest.NewRequest("GET", "/", nil), time.Time{}), "no connection")
+}
+
+func Test_ShutdownFlushesAccessLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	srv := newTestServer(t, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	}, WithAccessLog(accesslog.Config{Path: path}))
+
+	srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/1", nil))
+
+	// queued access log entries must be written even if the
+	// shutdown context has already expired.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = srv.Shutdown(ctx)
+
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(blob), "/test/1"), string(blob))
+}
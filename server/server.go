@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -8,6 +9,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/tierklinik-dobersberg/logger"
 	"github.com/tierklinik-dobersberg/service/accesslog"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PreHandlerFunc is called for each http request before the
@@ -23,14 +27,27 @@ type Server struct {
 	rw         sync.RWMutex
 	preHandler []PreHandlerFunc
 
-	logger     logger.Logger
-	listenCfgs []Listener
-	servers    []*http.Server
+	logger             logger.Logger
+	listenCfgs         []Listener
+	servers            []*http.Server
+	hideInternalErrors bool
+	catalog            *Catalog
+	accessLogCfg       accesslog.Config
+	accessLogWriter    *accesslog.BufferedFileWriter
+	accessLogClose     sync.Once
+	accessLogCloseErr  error
+	slowRequests       *accesslog.SlowRequests
+	tracerProvider     trace.TracerProvider
+	metrics            *Metrics
+	ready              chan struct{}
 }
 
-// New creates a new server instance.
+// New creates a new server instance. Access logs are written to
+// accessLogPath unless a different path is configured using
+// WithAccessLog.
 func New(accessLogPath string, opts ...Option) (*Server, error) {
 	srv := new(Server)
+	srv.ready = make(chan struct{})
 	srv.Engine = gin.New()
 	srv.Engine.Use(
 		gin.Recovery(),
@@ -46,31 +63,113 @@ func New(accessLogPath string, opts ...Option) (*Server, error) {
 		srv.logger = logger.DefaultLogger()
 	}
 
+	if srv.catalog == nil {
+		srv.catalog = DefaultCatalog
+	}
+
+	if srv.tracerProvider == nil {
+		srv.tracerProvider = otel.GetTracerProvider()
+	}
+
 	if len(srv.listenCfgs) == 0 {
 		return nil, fmt.Errorf("no listeners configured")
 	}
 
+	// parse trusted proxies once so we don't need to do that
+	// for each request.
+	for idx := range srv.listenCfgs {
+		if err := srv.listenCfgs[idx].ParseTrustedProxies(); err != nil {
+			return nil, err
+		}
+	}
+
+	if srv.accessLogCfg.Path == "" {
+		srv.accessLogCfg.Path = accessLogPath
+	}
+
 	// We always use an access logger, either printing to accessLogPath
 	// or to logger.DefaultLogger()
-	srv.Engine.Use(accessLogger(accessLogPath))
+	accessLogger, err := srv.accessLogger()
+	if err != nil {
+		return nil, fmt.Errorf("access log: %w", err)
+	}
+	srv.Engine.Use(
+		RequestIDMiddleware(),
+		TracingMiddleware(srv.tracerProvider),
+		accessLogger,
+	)
+	if srv.metrics != nil {
+		srv.Engine.Use(srv.metrics.trackInFlight)
+	}
 
 	return srv, nil
 }
 
-func accessLogger(path string) gin.HandlerFunc {
-	accessLogger := logger.DefaultLogger()
-	if path != "" {
+func (srv *Server) accessLogger() (gin.HandlerFunc, error) {
+	// access log entries are tagged with the accesslog component
+	// for the default adapter only so the access log file is not
+	// cluttered.
+	accessLogger := logging.Named(logger.DefaultLogger(), "accesslog")
+	if path := srv.accessLogCfg.Path; path != "" {
+		format, err := srv.accessLogCfg.Formatter()
+		if err != nil {
+			return nil, err
+		}
+
+		srv.accessLogWriter = &accesslog.BufferedFileWriter{
+			Path:         path,
+			ErrorAdapter: logger.DefaultAdapter(),
+			Rotation:     srv.accessLogCfg.RotationPolicy(),
+			Format:       format,
+		}
 		adapter := logger.MultiAdapter(
-			logger.DefaultAdapter(),
-			&accesslog.FileWriter{
-				Path:         path,
-				ErrorAdapter: logger.DefaultAdapter(),
-			},
+			logging.WithComponent(logger.DefaultAdapter(), "accesslog"),
+			srv.accessLogWriter,
 		)
 		accessLogger = logger.New(adapter)
 	}
 
-	return accesslog.New(accessLogger)
+	opts, err := srv.accessLogCfg.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	srv.slowRequests, err = srv.accessLogCfg.SlowRequests(srv.logger)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts,
+		accesslog.WithSlowRequests(srv.slowRequests),
+		accesslog.WithListenerName(listenerName),
+	)
+	if srv.metrics != nil {
+		opts = append(opts, accesslog.WithObserver(srv.metrics))
+	}
+
+	return accesslog.New(accessLogger, opts...), nil
+}
+
+// listenerName returns the name of the listener that accepted r.
+func listenerName(r *http.Request) string {
+	l, ok := r.Context().Value(ListenerKey).(*Listener)
+	if !ok || l == nil {
+		return ""
+	}
+	return l.DisplayName()
+}
+
+// SlowRequests returns the slow request detector of the server
+// that keeps latency histograms for all routes.
+func (srv *Server) SlowRequests() *accesslog.SlowRequests {
+	return srv.slowRequests
+}
+
+// ReopenAccessLog re-opens the access log file. It should be called
+// after the access log has been moved by external tools like logrotate.
+func (srv *Server) ReopenAccessLog() {
+	if srv.accessLogWriter != nil {
+		srv.accessLogWriter.Reopen()
+	}
 }
 
 // WithPreHandler adds additional pre-request handler function
@@ -97,6 +196,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// create a new request context that has a logger attached.
 	ctx := req.Context()
 	ctx = logger.With(ctx, srv.logger)
+	ctx = context.WithValue(ctx, serverKey, srv)
 
 	// create a new request with the new context
 	// and hand over to gin.Engine
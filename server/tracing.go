@@ -0,0 +1,74 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware returns a gin middleware that starts a server
+// span for each request using tp. Spans are named after the matched
+// route and continue W3C trace contexts sent by clients. The trace
+// and span IDs are added to the context logger (as
+// logging.TraceIDField and logging.SpanIDField) and the gin.Context
+// so they are part of the access log. It is installed by New.
+func TracingMiddleware(tp trace.TracerProvider) gin.HandlerFunc {
+	tracer := tp.Tracer(tracing.TracerName)
+
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(
+			c.Request.Context(),
+			propagation.HeaderCarrier(c.Request.Header),
+		)
+
+		route := c.FullPath()
+		name := route
+		if name == "" {
+			name = "HTTP " + c.Request.Method
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", c.Request)...),
+			trace.WithAttributes(semconv.EndUserAttributesFromHTTPRequest(c.Request)...),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", route, c.Request)...),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			traceID := sc.TraceID().String()
+			spanID := sc.SpanID().String()
+
+			// logger.WithFields and logger.With share the same context
+			// key so we need to store a new logger instead of fields.
+			ctx = logger.With(ctx, logger.From(ctx).WithFields(logger.Fields{
+				logging.TraceIDField: traceID,
+				logging.SpanIDField:  spanID,
+			}))
+
+			// public gin keys are added to the access log.
+			c.Set(logging.TraceIDField, traceID)
+			c.Set(logging.SpanIDField, spanID)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		// client errors are not errors of the server span.
+		if status >= 500 {
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+		}
+		if len(c.Errors) > 0 {
+			span.SetAttributes(attribute.String("gin.errors", c.Errors.String()))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gotest.tools/assert"
+)
+
+func Test_TracingMiddleware(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var logFields logger.Fields
+	srv := newTestServer(t, func(c *gin.Context) {
+		logger.From(c.Request.Context()).Info("handled")
+		c.Status(http.StatusInternalServerError)
+	},
+		WithTracerProvider(tp),
+		WithLogger(logger.New(logger.AdapterFunc(func(_ time.Time, _ logger.Severity, _ string, f logger.Fields) {
+			logFields = f
+		}))),
+	)
+
+	req := httptest.NewRequest("GET", "/test/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	doRequest(srv, req)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+
+	span := spans[0]
+	assert.Equal(t, "/test/:id", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Assert(t, span.Parent().IsRemote())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logFields[logging.TraceIDField])
+	assert.Equal(t, span.SpanContext().SpanID().String(), logFields[logging.SpanIDField])
+	assert.Assert(t, logFields[logging.RequestIDField] != nil)
+}
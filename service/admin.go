@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// AdminConfig configures the administrative HTTP endpoints of the
+// built-in HTTP server as read from the [Admin] section.
+type AdminConfig struct {
+	// Enabled enables the administrative endpoints.
+	Enabled bool
+
+	// PathPrefix is the path prefix for all administrative
+	// endpoints.
+	PathPrefix string
+
+	// Listeners holds the names of all listeners that serve the
+	// administrative endpoints. If empty, all listeners are
+	// used.
+	Listeners []string `option:"Listener"`
+}
+
+// AdminSpec defines the available configuration values for the
+// [Admin] section.
+var AdminSpec = conf.SectionSpec{
+	{
+		Name:        "Enabled",
+		Description: "Whether or not administrative HTTP endpoints should be enabled.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "PathPrefix",
+		Description: "The path prefix for all administrative HTTP endpoints.",
+		Type:        conf.StringType,
+		Default:     "/admin",
+	},
+	{
+		Name:        "Listener",
+		Description: "Names of the listeners that serve administrative HTTP endpoints. Defaults to all listeners.",
+		Type:        conf.StringSliceType,
+	},
+}
+
+// AdminRoutes returns the router group for administrative endpoints.
+// It returns nil if the built-in HTTP server is disabled or the
+// [Admin] section does not enable administrative endpoints. Note
+// that administrative endpoints don't have any authentication so
+// the group should be restricted to a private listener.
+func (inst *Instance) AdminRoutes() gin.IRouter {
+	if inst.admin == nil {
+		return nil
+	}
+	return inst.admin
+}
+
+func prepareAdmin(cfg *Config, inst *Instance) error {
+	if inst.srv == nil {
+		return nil
+	}
+
+	var file struct {
+		Admin AdminConfig `section:"Admin"`
+	}
+	if err := conf.DecodeFile(inst.cfgFile, &file, cfg); err != nil {
+		return fmt.Errorf("failed to parse admin section: %w", err)
+	}
+
+	if !file.Admin.Enabled {
+		return nil
+	}
+
+	prefix := file.Admin.PathPrefix
+	if prefix == "" {
+		prefix = "/admin"
+	}
+
+	inst.admin = inst.srv.Group(prefix, server.RequireListener(file.Admin.Listeners...))
+	inst.setupLogLevelRoutes(inst.admin)
+	if inst.logBuffer != nil {
+		inst.setupLogBufferRoutes(inst.admin)
+	}
+	inst.setupSchedulerRoutes(inst.admin)
+
+	if slow := inst.srv.SlowRequests(); slow != nil {
+		inst.admin.GET("/latency", slow.Handler())
+	}
+
+	return nil
+}
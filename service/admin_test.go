@@ -0,0 +1,47 @@
+package service
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+	"gotest.tools/assert"
+)
+
+func Test_AdminLatency(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "admin.conf"), []byte("[Admin]\nEnabled=yes\n\n[CORS]\nAllowOrigins=*\n"), 0644))
+
+	inst, err := Boot(Config{
+		ConfigDirectory:  dir,
+		ConfigSchema:     conf.FileSpec{},
+		DisableLogBuffer: true,
+		RouteSetupFunc: func(grp gin.IRouter) error {
+			grp.GET("/patients/:id", func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+			return nil
+		},
+	})
+	assert.NilError(t, err)
+
+	rec := httptest.NewRecorder()
+	inst.Server().ServeHTTP(rec, httptest.NewRequest("GET", "/patients/1", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	inst.Server().ServeHTTP(rec, httptest.NewRequest("GET", "/admin/latency", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var routes []accesslog.RouteLatency
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &routes))
+	assert.Equal(t, 1, len(routes))
+	assert.Equal(t, "/patients/:id", routes[0].Route)
+	assert.Equal(t, uint64(1), routes[0].Count)
+}
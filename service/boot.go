@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,31 +13,98 @@ import (
 
 	"github.com/ppacher/system-conf/conf"
 	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+	"github.com/tierklinik-dobersberg/service/health"
+	"github.com/tierklinik-dobersberg/service/lifecycle"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/pidfile"
 	"github.com/tierklinik-dobersberg/service/server"
 	"github.com/tierklinik-dobersberg/service/svcenv"
+	"github.com/tierklinik-dobersberg/service/tracing"
 )
 
 // Boot boots the service and returns the service
 // instance.
-func Boot(cfg Config) (*Instance, error) {
+func Boot(cfg Config) (inst *Instance, err error) {
 	// setup logging
-	log := new(logAdapter)
-	log.setMaxSeverity(cfg.LogLevel)
+	log := newLogAdapter(cfg.LogLevel)
 	if cfg.UseStdlibLogAdapter {
 		log.addAdapter(new(logger.StdlibAdapter))
 	}
 
+	// the log buffer is added before loading the configuration
+	// so it contains early log messages as well.
+	var logBuffer *logging.RingBuffer
+	if !cfg.DisableLogBuffer {
+		logBuffer = logging.NewRingBuffer(logging.DefaultRingBufferSize)
+		log.addAdapter(logBuffer)
+	}
+
+	// metrics are created early so all log messages are
+	// counted.
+	metrics := newServiceMetrics()
+	log.addAdapter(metrics)
+
 	logger.SetDefaultAdapter(log)
 
+	if err := cfg.checkSchema(); err != nil {
+		return nil, err
+	}
+
 	// load the service environment
 	env := svcenv.Env()
 
+	var (
+		pidFile         *pidfile.PIDFile
+		shutdownTracing tracing.ShutdownFunc
+	)
+
+	// release everything acquired so far if booting fails.
+	defer func() {
+		if err == nil {
+			return
+		}
+		if shutdownTracing != nil {
+			if terr := shutdownTracing(context.Background()); terr != nil {
+				logger.DefaultLogger().Errorf("failed to shutdown tracing: %s", terr)
+			}
+		}
+		if pidFile != nil {
+			if perr := pidFile.Release(); perr != nil {
+				logger.DefaultLogger().Errorf("failed to release PID file: %s", perr)
+			}
+		}
+	}()
+
+	// make sure we're the only instance before touching any
+	// state.
+	if cfg.SingleInstance {
+		if !pidfile.LockSupported {
+			logger.DefaultLogger().V(logging.LevelWarning).Logf("PID file locking is not supported on this platform, SingleInstance is not enforced")
+		}
+
+		pidFile, err = pidfile.Acquire(pidFilePath(&cfg, env))
+		var locked *pidfile.LockedError
+		if errors.As(err, &locked) {
+			return nil, fmt.Errorf("another instance is already running: %w", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire PID file: %w", err)
+		}
+	}
+
 	// load the configuration file
 	cfgFile, err := loadConfig(env, &cfg)
 	if err != nil {
 		return nil, fmt.Errorf("configuration: %w", err)
 	}
 
+	// apply log levels and add log outputs configured in the
+	// [Log] section.
+	if err := setupLogging(&cfg, cfgFile, log, logBuffer); err != nil {
+		return nil, fmt.Errorf("log: %w", err)
+	}
+
 	// If there's a receiver target for the configuration
 	// directly decode it there.
 	if cfg.ConfigTarget != nil {
@@ -47,11 +116,33 @@ func Boot(cfg Config) (*Instance, error) {
 		}
 	}
 
-	inst := &Instance{
+	// configure OpenTelemetry tracing from the [Tracing] section.
+	shutdownTracing, err = setupTracing(&cfg, cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	inst = &Instance{
 		Config:     cfg,
 		ServiceEnv: env,
 		cfgFile:    cfgFile,
 		logAdapter: log,
+		logBuffer:  logBuffer,
+		httpClient: &http.Client{
+			Transport: &server.RequestIDTransport{
+				Base: new(tracing.Transport),
+			},
+		},
+		metrics:         metrics,
+		health:          health.NewRegistry(),
+		lifecycle:       lifecycle.NewManager(logging.Named(logger.DefaultLogger(), "lifecycle")),
+		shutdownTracing: shutdownTracing,
+		pidFile:         pidFile,
+		stopping:        make(chan struct{}),
+	}
+
+	if err := prepareScheduler(&cfg, inst); err != nil {
+		return nil, err
 	}
 
 	// prepare the built-in HTTP server
@@ -61,9 +152,74 @@ func Boot(cfg Config) (*Instance, error) {
 	}
 	inst.srv = srv
 
+	if err := prepareAdmin(&cfg, inst); err != nil {
+		return nil, err
+	}
+
+	if err := prepareMetrics(&cfg, inst); err != nil {
+		return nil, err
+	}
+
+	if err := prepareHealth(&cfg, inst); err != nil {
+		return nil, err
+	}
+
 	return inst, nil
 }
 
+func setupLogging(cfg *Config, cfgFile *conf.File, log *logAdapter, buf *logging.RingBuffer) error {
+	var file struct {
+		Log logging.Config `section:"Log"`
+	}
+	if err := conf.DecodeFile(cfgFile, &file, cfg); err != nil {
+		return err
+	}
+
+	if err := log.levels.Parse(file.Log.Level); err != nil {
+		return err
+	}
+	log.configLevels = file.Log.Level
+
+	if buf != nil && file.Log.BufferSize > 0 {
+		buf.Resize(file.Log.BufferSize)
+	}
+
+	outputs := file.Log.Output[:0]
+	for _, o := range file.Log.Output {
+		// the stdlib adapter has already been added.
+		if cfg.UseStdlibLogAdapter && strings.EqualFold(o, logging.OutputStderr) {
+			continue
+		}
+		outputs = append(outputs, o)
+	}
+	file.Log.Output = outputs
+
+	adapters, err := file.Log.Adapters()
+	if err != nil {
+		return err
+	}
+	for _, a := range adapters {
+		log.addAdapter(a)
+	}
+
+	return nil
+}
+
+func setupTracing(cfg *Config, cfgFile *conf.File) (tracing.ShutdownFunc, error) {
+	var file struct {
+		Tracing tracing.Config `section:"Tracing"`
+	}
+	// SampleRatio defaults to 1 even if there's no [Tracing]
+	// section.
+	file.Tracing.SampleRatio = 1
+
+	if err := conf.DecodeFile(cfgFile, &file, cfg); err != nil {
+		return nil, err
+	}
+
+	return tracing.Setup(context.Background(), file.Tracing)
+}
+
 func prepareHTTPServer(cfg *Config, inst *Instance) (*server.Server, error) {
 	if cfg.DisableServer {
 		return nil, nil
@@ -74,6 +230,7 @@ func prepareHTTPServer(cfg *Config, inst *Instance) (*server.Server, error) {
 	var file struct {
 		Listeners []server.Listener `section:"Listener"`
 		CORS      *server.CORS      `section:"CORS"`
+		AccessLog accesslog.Config  `section:"AccessLog"`
 	}
 
 	// Prepare default values for cors
@@ -115,9 +272,14 @@ func prepareHTTPServer(cfg *Config, inst *Instance) (*server.Server, error) {
 
 	options := []server.Option{
 		server.WithListener(file.Listeners...),
-		server.WithLogger(logger.DefaultLogger()),
+		server.WithLogger(logging.Named(logger.DefaultLogger(), "server")),
+		server.WithAccessLog(file.AccessLog),
+		server.WithMetrics(inst.metrics.registry),
 		inst.serverOption(),
 	}
+	if cfg.HideInternalErrors {
+		options = append(options, server.WithHideInternalErrors())
+	}
 	options = append(options, cfg.ServerOptions...)
 
 	// prepare the actual HTTP server ...
@@ -154,7 +316,7 @@ func loadConfig(env svcenv.ServiceEnv, cfg *Config) (*conf.File, error) {
 	// The configuration file is either located in env.ConfigurationDirectory
 	// or in the current working-directory of the service.
 	// TODO(ppacher): add support to disable the WD fallback.
-	log := logger.From(context.TODO())
+	log := logging.Named(logger.From(context.TODO()), "config")
 
 	dir := env.ConfigurationDirectory
 	if dir == "" {
@@ -207,10 +369,10 @@ func loadConfig(env svcenv.ServiceEnv, cfg *Config) (*conf.File, error) {
 
 		sort.Strings(matches)
 		for _, file := range matches {
-			logger.From(context.TODO()).V(5).Logf("found configuration file: %s", file)
+			log.V(5).Logf("found configuration file: %s", file)
 			f, err := os.Open(file)
 			if err != nil {
-				logger.Errorf(context.TODO(), "failed to open %s: %s, skipping", file, err)
+				log.Errorf("failed to open %s: %s, skipping", file, err)
 				continue
 			}
 			defer f.Close()
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ppacher/system-conf/conf"
 	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/accesslog"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/scheduler"
 	"github.com/tierklinik-dobersberg/service/server"
+	"github.com/tierklinik-dobersberg/service/tracing"
 )
 
 // Config describes the overall configuration and setup required
@@ -15,7 +21,9 @@ type Config struct {
 	// AccessLogPath is the path to the access log of the
 	// built-in HTTP server. If defined as "AccessLogPath"
 	// by ConfigSchema, the access log may be overwritten
-	// by the configuration file automatically.
+	// by the configuration file automatically. The Path
+	// option of the [AccessLog] section takes precedence
+	// over AccessLogPath.
 	AccessLogPath string
 
 	// ConfigFileName is the name of the configuration file.
@@ -28,9 +36,15 @@ type Config struct {
 	ConfigDirectory string
 
 	// UseStdlibLogAdapter can be set to true to immediately add a new
-	// logger.StandardAdapter to the service logger.
+	// logger.StandardAdapter to the service logger. Additional log
+	// outputs like journald or syslog can be configured in the [Log]
+	// section of the configuration file.
 	UseStdlibLogAdapter bool
 
+	// DisableLogBuffer disables the in-memory buffer of recent
+	// log entries. See Instance.LogBuffer.
+	DisableLogBuffer bool
+
 	// LogLevel can be set to the default log level. This can later be
 	// overwritten by using instance.SetLogLevel().
 	LogLevel logger.Severity
@@ -43,6 +57,12 @@ type Config struct {
 	// If no [Listener] section is defined and the built-in
 	// HTTP server is enabled a default listener for
 	// 127.0.0.1:3000 is created.
+	// The sections [Log], [Tracing] and [Job] as well as
+	// [Listener], [CORS], [AccessLog], [Admin], [Metrics]
+	// and [Health] (unless DisableServer is set) are
+	// reserved. Boot fails if ConfigSchema defines any of
+	// them except [Listener] and [CORS], which always
+	// replace the sections of ConfigSchema.
 	ConfigSchema conf.SectionRegistry
 
 	// ConfigTarget may holds the struct that should be
@@ -50,13 +70,38 @@ type Config struct {
 	ConfigTarget interface{}
 
 	// DisableServer disables the built-in HTTP(s) server.
-	// If set, calls to Server() will return nil!
+	// If set, calls to Server() will return nil and Serve
+	// only manages the components added to the instance.
 	DisableServer bool
 
 	// DisableCORS disables automatic support for CORS
 	// configuration using the global configuration file.
 	DisableCORS bool
 
+	// HideInternalErrors can be set to true to not include
+	// error messages in problem responses with a 5xx status
+	// code. See server.WithHideInternalErrors.
+	HideInternalErrors bool
+
+	// SingleInstance makes Boot acquire an exclusive lock on a
+	// PID file so only one instance of the service can run at a
+	// time. The lock is released when Serve returns or
+	// Instance.Close is called. Locking is only supported on
+	// unix systems; a warning is logged on other platforms.
+	SingleInstance bool
+
+	// PIDFile is the path of the PID file used for SingleInstance.
+	// Relative paths are resolved against the RuntimeDirectory of
+	// the service environment if RUNTIME_DIRECTORY is set and
+	// against the StateDirectory otherwise. Defaults to the
+	// ConfigFileName with a .pid extension.
+	PIDFile string
+
+	// ShutdownTimeout bounds the graceful shutdown of the HTTP
+	// server and all components. Defaults to
+	// DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
 	// ServerOptions may hold additional options for the
 	// built-in HTTP server. ServerOptions is ignored when
 	// DisableServer is set.
@@ -69,15 +114,67 @@ type Config struct {
 	RouteSetupFunc func(grp gin.IRouter) error
 }
 
-func (cfg *Config) OptionsForSection(secName string) (conf.OptionRegistry, bool) {
-	lowerName := strings.ToLower(secName)
+// builtinSection is a configuration file section provided by
+// the service package.
+type builtinSection struct {
+	Name string
+	Spec conf.OptionRegistry
+}
+
+// builtinSections returns all sections provided by the service
+// package for cfg.
+func (cfg *Config) builtinSections() []builtinSection {
+	sections := []builtinSection{
+		{"Log", logging.ConfigSpec},
+		{"Tracing", tracing.ConfigSpec},
+		{"Job", scheduler.ConfigSpec},
+	}
 	if !cfg.DisableServer {
-		if lowerName == "listener" {
-			return server.ListenerSpec, true
+		sections = append(sections, builtinSection{"Listener", server.ListenerSpec})
+		if !cfg.DisableCORS {
+			sections = append(sections, builtinSection{"CORS", server.CORSSpec})
 		}
+		sections = append(sections,
+			builtinSection{"AccessLog", accesslog.ConfigSpec},
+			builtinSection{"Admin", AdminSpec},
+			builtinSection{"Metrics", MetricsSpec},
+			builtinSection{"Health", HealthSpec},
+		)
+	}
+
+	return sections
+}
 
-		if !cfg.DisableCORS && lowerName == "cors" {
-			return server.CORSSpec, true
+// checkSchema returns an error if ConfigSchema defines a section
+// that is provided by the service package. [Listener] and [CORS]
+// are not checked as they have always replaced the sections of
+// ConfigSchema.
+func (cfg *Config) checkSchema() error {
+	if cfg.ConfigSchema == nil {
+		return nil
+	}
+
+	var reserved []string
+	for _, sec := range cfg.builtinSections() {
+		if sec.Name == "Listener" || sec.Name == "CORS" {
+			continue
+		}
+		if _, ok := cfg.ConfigSchema.OptionsForSection(sec.Name); ok {
+			reserved = append(reserved, sec.Name)
+		}
+	}
+
+	if len(reserved) > 0 {
+		return fmt.Errorf("ConfigSchema must not define the reserved sections %s", strings.Join(reserved, ", "))
+	}
+
+	return nil
+}
+
+func (cfg *Config) OptionsForSection(secName string) (conf.OptionRegistry, bool) {
+	for _, sec := range cfg.builtinSections() {
+		if strings.EqualFold(sec.Name, secName) {
+			return sec.Spec, true
 		}
 	}
 	if cfg.ConfigSchema != nil {
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/service/health"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// HealthConfig configures the liveness and readiness endpoints of
+// the built-in HTTP server as read from the [Health] section.
+type HealthConfig struct {
+	// Enabled enables the health endpoints.
+	Enabled bool
+
+	// LivenessPath is the path of the liveness endpoint.
+	LivenessPath string
+
+	// ReadinessPath is the path of the readiness endpoint.
+	ReadinessPath string
+
+	// DrainDelay is the time to wait after readiness started
+	// failing before the listeners are closed during graceful
+	// shutdown.
+	DrainDelay time.Duration
+
+	// Listeners holds the names of all listeners that serve the
+	// health endpoints. If empty, all listeners are used.
+	Listeners []string `option:"Listener"`
+}
+
+// HealthSpec defines the available configuration values for the
+// [Health] section.
+var HealthSpec = conf.SectionSpec{
+	{
+		Name:        "Enabled",
+		Description: "Whether or not the liveness and readiness endpoints should be enabled.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "LivenessPath",
+		Description: "The path of the liveness endpoint.",
+		Type:        conf.StringType,
+		Default:     "/healthz",
+	},
+	{
+		Name:        "ReadinessPath",
+		Description: "The path of the readiness endpoint.",
+		Type:        conf.StringType,
+		Default:     "/readyz",
+	},
+	{
+		Name:        "DrainDelay",
+		Description: "Time to wait after readiness started failing before listeners are closed during graceful shutdown. Counts against the shutdown timeout.",
+		Type:        conf.DurationType,
+	},
+	{
+		Name:        "Listener",
+		Description: "Names of the listeners that serve the health endpoints. Defaults to all listeners.",
+		Type:        conf.StringSliceType,
+	},
+}
+
+// Health returns the health check registry of the instance. Use it
+// to register checks that are exposed on the liveness and readiness
+// endpoints.
+func (inst *Instance) Health() *health.Registry {
+	return inst.health
+}
+
+func prepareHealth(cfg *Config, inst *Instance) error {
+	if inst.srv == nil {
+		return nil
+	}
+
+	var file struct {
+		Health HealthConfig `section:"Health"`
+	}
+	file.Health = HealthConfig{
+		LivenessPath:  "/healthz",
+		ReadinessPath: "/readyz",
+	}
+
+	if err := conf.DecodeFile(inst.cfgFile, &file, cfg); err != nil {
+		return fmt.Errorf("failed to parse health section: %w", err)
+	}
+	inst.drainDelay = file.Health.DrainDelay
+
+	if !file.Health.Enabled {
+		return nil
+	}
+
+	requireListener := server.RequireListener(file.Health.Listeners...)
+	inst.handleGET(
+		file.Health.LivenessPath,
+		requireListener,
+		gin.WrapH(inst.health.LivenessHandler()),
+	)
+	inst.handleGET(
+		file.Health.ReadinessPath,
+		requireListener,
+		gin.WrapH(inst.health.ReadinessHandler()),
+	)
+
+	return nil
+}
@@ -2,27 +2,69 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/ory/graceful"
+	"github.com/gin-gonic/gin"
 	"github.com/ppacher/system-conf/conf"
 	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/health"
+	"github.com/tierklinik-dobersberg/service/lifecycle"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/pidfile"
+	"github.com/tierklinik-dobersberg/service/scheduler"
+	"github.com/tierklinik-dobersberg/service/sdnotify"
 	"github.com/tierklinik-dobersberg/service/server"
 	"github.com/tierklinik-dobersberg/service/svcenv"
+	"github.com/tierklinik-dobersberg/service/tracing"
 )
 
 type contextKey string
 
+// closedChan is an already closed channel.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
 const instanceContextKey = contextKey("service:instance")
 
 type Instance struct {
 	Config
 	svcenv.ServiceEnv
 
-	cfgFile    *conf.File
+	reloadLock  sync.Mutex
+	cfgLock     sync.RWMutex
+	cfgFile     *conf.File
+	reloadHooks []ReloadFunc
+
 	srv        *server.Server
 	logAdapter *logAdapter
+	logBuffer  *logging.RingBuffer
+	admin      *gin.RouterGroup
+	httpClient *http.Client
+	metrics    *serviceMetrics
+	health     *health.Registry
+	lifecycle  *lifecycle.Manager
+	scheduler  *scheduler.Scheduler
+	pidFile    *pidfile.PIDFile
+	drainDelay time.Duration
+
+	// stopping is closed once the shutdown begins.
+	stopping     chan struct{}
+	stoppingOnce sync.Once
+
+	closeOnce sync.Once
+	closeErr  error
+
+	shutdownTracing tracing.ShutdownFunc
 }
 
 // FromContext returns the service instance associated
@@ -40,9 +82,21 @@ func (inst *Instance) Server() *server.Server {
 	return inst.srv
 }
 
+// HTTPClient returns a HTTP client that forwards the request ID
+// of the incoming request to other services. Use it together with
+// http.NewRequestWithContext and the context of the incoming
+// request.
+func (inst *Instance) HTTPClient() *http.Client {
+	return inst.httpClient
+}
+
 // ConfigFile returns the parsed conf.File content
-// of the service configuration file.
+// of the service configuration file. The returned file
+// changes when the configuration is reloaded.
 func (inst *Instance) ConfigFile() *conf.File {
+	inst.cfgLock.RLock()
+	defer inst.cfgLock.RUnlock()
+
 	return inst.cfgFile
 }
 
@@ -54,29 +108,236 @@ func (inst *Instance) AddLogger(adapter logger.Adapter) {
 	inst.logAdapter.addAdapter(adapter)
 }
 
-// SetLogLevel configures the maximum log level for the
-// instance logger.
+// SetLogLevel configures the default maximum log level for the
+// instance logger. Components with an individual log level are
+// not affected. See SetComponentLogLevel.
 func (inst *Instance) SetLogLevel(s logger.Severity) {
-	inst.logAdapter.setMaxSeverity(s)
+	inst.logAdapter.levels.SetDefault(s)
 }
 
-// Serve starts serving the internal, built-in HTTP server.
-// It blocks forever but listens for typical server interrupt
-// signals like SIGINT and SIGTERM. In case of a signal the
-// server is gracefully brought down while in-flight requests
-// are allowed to finish.
+// Serve starts all components registered using AddComponent and
+// serves the internal, built-in HTTP server afterwards. It blocks
+// until SIGINT or SIGTERM is received, the server fails or a
+// component fails and cannot be restarted. The server is then
+// gracefully brought down while in-flight requests are allowed
+// to finish and all components are stopped in reverse order.
+// The whole shutdown is bounded by Config.ShutdownTimeout.
+//
+// If the built-in HTTP server is disabled, Serve runs headless
+// and also returns once all components added using
+// lifecycle.Worker have finished.
+//
+// Readiness starts failing as soon as the shutdown begins and
+// listeners are closed after the DrainDelay configured in the
+// [Health] section. When running as a systemd Type=notify unit,
+// READY=1 is sent once all listeners are bound, STOPPING=1 when
+// the shutdown begins and WATCHDOG=1 periodically as long as all
+// liveness checks pass.
+//
+// On SIGHUP the access log file is re-opened to support external
+// log rotation and the configuration is reloaded (see Reload).
+// SIGUSR1 and SIGUSR2 increase and decrease the default log level.
 func (inst *Instance) Serve() error {
-	if inst.srv == nil {
-		return fmt.Errorf("built-in HTTP server is disabled")
+	log := logger.DefaultLogger()
+
+	stopSignals := inst.handleSignals()
+	defer stopSignals()
+
+	// trap SIGINT and SIGTERM before starting anything so we
+	// always shut down gracefully.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	// cancel the startup on SIGINT or SIGTERM so components
+	// waiting for a restart backoff do not block us. The signal
+	// is passed back to stop for the main loop below.
+	startCtx, cancelStart := context.WithCancel(context.Background())
+	startDone := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-stop:
+			cancelStart()
+			select {
+			case stop <- sig:
+			default:
+			}
+		case <-startDone:
+		}
+	}()
+
+	err := inst.startComponents(startCtx)
+	close(startDone)
+	cancelStart()
+
+	if err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), inst.shutdownTimeout())
+		defer cancel()
+
+		// nothing is running so this only releases resources.
+		_ = inst.shutdown(ctx, nil)
+
+		return fmt.Errorf("failed to start components: %w", err)
+	}
+
+	stopWatchdog := inst.startWatchdog()
+	defer stopWatchdog()
+
+	var (
+		runErr chan error
+		ready  <-chan struct{}
+		done   <-chan struct{}
+	)
+	if inst.srv != nil {
+		runErr = make(chan error, 1)
+		go func() {
+			runErr <- inst.srv.Run()
+		}()
+		ready = inst.srv.Ready()
+	} else {
+		// headless, we're ready as soon as all components
+		// are started and done once all workers finished.
+		ready = closedChan
+		done = inst.lifecycle.Done()
+	}
+
+	running := true
+	for running {
+		select {
+		case <-ready:
+			// tell systemd we're ready once all listeners
+			// are bound.
+			notify(sdnotify.Ready, sdnotify.Status("serving"))
+			ready = nil
+		case sig := <-stop:
+			log.Infof("received %s, shutting down", sig)
+			running = false
+		case err = <-runErr:
+			running = false
+			runErr = nil
+		case err = <-inst.lifecycle.Failed():
+			running = false
+		case <-done:
+			// a failure is always reported before all
+			// components are done.
+			select {
+			case err = <-inst.lifecycle.Failed():
+			default:
+				log.Info("all components finished, shutting down")
+			}
+			running = false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), inst.shutdownTimeout())
+	defer cancel()
+
+	if shutdownErr := inst.shutdown(ctx, runErr); err == nil {
+		err = shutdownErr
 	}
 
-	if err := graceful.Graceful(inst.srv.Run, inst.srv.Shutdown); err != nil {
-		return fmt.Errorf("graceful: %w", err)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
 
 	return nil
 }
 
+// shutdown gracefully stops the HTTP server and all components.
+// runErr is the channel that receives the result of
+// server.Server.Run or nil if the server is not running anymore.
+func (inst *Instance) shutdown(ctx context.Context, runErr <-chan error) error {
+	log := logger.DefaultLogger()
+
+	notify(sdnotify.Stopping, sdnotify.Status("shutting down"))
+	inst.stoppingOnce.Do(func() {
+		close(inst.stopping)
+	})
+
+	// fail readiness checks so load balancers stop sending
+	// new requests before the listeners are closed.
+	inst.health.SetShuttingDown()
+
+	var err error
+	if runErr != nil {
+		if inst.drainDelay > 0 {
+			log.Infof("draining for %s before shutdown", inst.drainDelay)
+			select {
+			case <-time.After(inst.drainDelay):
+			case <-ctx.Done():
+			}
+		}
+
+		err = inst.srv.Shutdown(ctx)
+
+		select {
+		case <-runErr:
+		case <-ctx.Done():
+		}
+	}
+
+	// components are stopped after all requests have finished.
+	if stopErr := inst.lifecycle.Stop(ctx); stopErr != nil {
+		log.Errorf("failed to stop components: %s", stopErr)
+		if err == nil {
+			err = stopErr
+		}
+	}
+
+	// flush pending spans last. Errors are logged by close.
+	_ = inst.close(ctx)
+
+	return err
+}
+
+// Close releases the resources acquired by Boot. Pending trace
+// spans are flushed and the PID file used for Config.SingleInstance
+// is released. Serve does this on its own so Close is only required
+// if Serve is not called, for example by command line tools. Close
+// may be called multiple times and after Serve returned.
+func (inst *Instance) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), inst.shutdownTimeout())
+	defer cancel()
+
+	return inst.close(ctx)
+}
+
+func (inst *Instance) close(ctx context.Context) error {
+	inst.closeOnce.Do(func() {
+		log := logger.DefaultLogger()
+
+		if err := inst.shutdownTracing(ctx); err != nil {
+			log.Errorf("failed to shutdown tracing: %s", err)
+			inst.closeErr = fmt.Errorf("failed to shutdown tracing: %w", err)
+		}
+
+		if inst.pidFile != nil {
+			if err := inst.pidFile.Release(); err != nil {
+				log.Errorf("failed to release PID file: %s", err)
+				if inst.closeErr == nil {
+					inst.closeErr = fmt.Errorf("failed to release PID file: %w", err)
+				}
+			}
+		}
+	})
+
+	return inst.closeErr
+}
+
+// handleGET registers handlers for GET path on the built-in HTTP
+// server unless the route already exists, for example because it
+// has been added by Config.RouteSetupFunc.
+func (inst *Instance) handleGET(path string, handlers ...gin.HandlerFunc) {
+	for _, r := range inst.srv.Routes() {
+		if r.Method == http.MethodGet && r.Path == path {
+			logger.DefaultLogger().Infof("route GET %s is already registered, skipping", path)
+			return
+		}
+	}
+
+	inst.srv.GET(path, handlers...)
+}
+
 func (inst *Instance) serverOption() server.Option {
 	return server.WithPreHandler(func(r *http.Request) *http.Request {
 		newCtx := context.WithValue(r.Context(), instanceContextKey, inst)
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppacher/system-conf/conf"
+	"gotest.tools/assert"
+)
+
+func Test_InstanceClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.pid")
+
+	inst, err := Boot(Config{
+		ConfigDirectory:  t.TempDir(),
+		ConfigSchema:     conf.FileSpec{},
+		DisableServer:    true,
+		DisableLogBuffer: true,
+		SingleInstance:   true,
+		PIDFile:          path,
+	})
+	assert.NilError(t, err)
+
+	_, err = os.Stat(path)
+	assert.NilError(t, err)
+
+	assert.NilError(t, inst.Close())
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err))
+
+	// closing again is a no-op.
+	assert.NilError(t, inst.Close())
+}
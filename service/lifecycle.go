@@ -0,0 +1,28 @@
+package service
+
+import (
+	"time"
+
+	"github.com/tierklinik-dobersberg/service/lifecycle"
+)
+
+// DefaultShutdownTimeout is used if Config.ShutdownTimeout is not
+// set.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// AddComponent registers a component that is started by Serve
+// before the listeners are opened and stopped after they have
+// been drained. Use lifecycle.DependsOn to declare dependencies
+// between components and lifecycle.WithRestartPolicy to restart
+// failed components. Use lifecycle.Worker for background jobs.
+// Components must be added before Serve is called.
+func (inst *Instance) AddComponent(name string, c lifecycle.Component, opts ...lifecycle.Option) error {
+	return inst.lifecycle.Register(name, c, opts...)
+}
+
+func (inst *Instance) shutdownTimeout() time.Duration {
+	if inst.ShutdownTimeout > 0 {
+		return inst.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
@@ -5,23 +5,40 @@ import (
 	"time"
 
 	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
 )
 
 type logAdapter struct {
-	maxSeverity logger.Severity
-	rw          sync.RWMutex
-	adapters    []logger.Adapter
+	levels *logging.Levels
+	// configLevels holds the levels applied from the [Log]
+	// section. See logging.Levels.Reload.
+	configLevels string
+	rw           sync.RWMutex
+	adapters     []logger.Adapter
+}
+
+func newLogAdapter(level logger.Severity) *logAdapter {
+	return &logAdapter{
+		levels: logging.NewLevels(level),
+	}
 }
 
 // Write implements logger.Adapter
 func (l *logAdapter) Write(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
-	l.rw.RLock()
-	defer l.rw.RUnlock()
-
-	if severity > l.maxSeverity {
+	component, _ := fields[logging.ComponentField].(string)
+	if !l.levels.Enabled(component, severity) {
 		return
 	}
 
+	l.writeAll(clock, severity, msg, fields)
+}
+
+// writeAll writes the message to all adapters ignoring the
+// configured log levels.
+func (l *logAdapter) writeAll(clock time.Time, severity logger.Severity, msg string, fields logger.Fields) {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
 	for _, adapter := range l.adapters {
 		adapter.Write(clock, severity, msg, fields)
 	}
@@ -32,9 +49,3 @@ func (l *logAdapter) addAdapter(a logger.Adapter) {
 	defer l.rw.Unlock()
 	l.adapters = append(l.adapters, a)
 }
-
-func (l *logAdapter) setMaxSeverity(s logger.Severity) {
-	l.rw.Lock()
-	defer l.rw.Unlock()
-	l.maxSeverity = s
-}
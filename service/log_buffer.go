@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// InvalidLogFilterCode is used as the problem code if an invalid
+// filter is passed to the administrative log endpoints.
+const InvalidLogFilterCode = "INVALID_LOG_FILTER"
+
+// logStreamKeepAlive is the interval at which comments are sent
+// to log stream clients to keep the connection open.
+const logStreamKeepAlive = 15 * time.Second
+
+// logStreamWriteTimeout is the write timeout used for each message
+// sent to log stream clients. It replaces the WriteTimeout of the
+// listener which would end all streams early.
+const logStreamWriteTimeout = 10 * time.Second
+
+// LogBuffer returns the in-memory buffer holding the most recent
+// log entries of the instance. It returns nil if
+// Config.DisableLogBuffer is set.
+func (inst *Instance) LogBuffer() *logging.RingBuffer {
+	return inst.logBuffer
+}
+
+func (inst *Instance) setupLogBufferRoutes(grp gin.IRouter) {
+	buf := inst.logBuffer
+
+	parseFilter := func(ctx *gin.Context) (logging.Filter, bool) {
+		filter, err := logging.ParseFilter(ctx.Request.URL.Query())
+		if err != nil {
+			server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusBadRequest, InvalidLogFilterCode, err.Error(), err))
+			return filter, false
+		}
+		return filter, true
+	}
+
+	grp.GET("/log/entries", func(ctx *gin.Context) {
+		filter, ok := parseFilter(ctx)
+		if !ok {
+			return
+		}
+
+		entries := buf.Entries(filter)
+		if entries == nil {
+			entries = []logging.Entry{}
+		}
+		ctx.JSON(http.StatusOK, entries)
+	})
+
+	// log entries are streamed as Server-Sent Events. Clients that
+	// reconnect with a Last-Event-ID header receive all entries
+	// they missed as long as they are still buffered.
+	grp.GET("/log/stream", func(ctx *gin.Context) {
+		filter, ok := parseFilter(ctx)
+		if !ok {
+			return
+		}
+
+		var backlog []logging.Entry
+		if lastID := ctx.GetHeader("Last-Event-ID"); lastID != "" {
+			id, err := strconv.ParseUint(lastID, 10, 64)
+			if err != nil {
+				server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusBadRequest, InvalidLogFilterCode, "invalid Last-Event-ID", err))
+				return
+			}
+			filter.AfterID = id
+		}
+
+		// subscribe before reading the backlog so no entries are
+		// lost in between.
+		ch, cancel := buf.Subscribe(filter, 100)
+		defer cancel()
+
+		if filter.AfterID > 0 {
+			backlog = buf.Entries(filter)
+		}
+
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("X-Accel-Buffering", "no")
+		ctx.Status(http.StatusOK)
+
+		// the stream outlives the WriteTimeout of the listener
+		// so the deadline is extended before each write.
+		extendDeadline := func() {
+			if err := server.SetWriteDeadline(ctx.Request, time.Now().Add(logStreamWriteTimeout)); err != nil {
+				logger.From(ctx.Request.Context()).V(5).Logf("failed to set write deadline: %s", err)
+			}
+		}
+		extendDeadline()
+
+		var lastSent uint64
+		send := func(e logging.Entry) error {
+			if e.ID <= lastSent {
+				return nil
+			}
+			lastSent = e.ID
+			extendDeadline()
+
+			blob, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: log\ndata: %s\n\n", e.ID, blob)
+			return err
+		}
+
+		for _, e := range backlog {
+			if err := send(e); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+
+		keepAlive := time.NewTicker(logStreamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case e := <-ch:
+				if err := send(e); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				extendDeadline()
+				if _, err := fmt.Fprint(ctx.Writer, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-ctx.Request.Context().Done():
+				return
+			case <-inst.stopping:
+				// the server waits for all requests to finish
+				// during shutdown.
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// InvalidLogLevelCode is used as the problem code if an invalid
+// log level is passed to the administrative log level endpoints.
+const InvalidLogLevelCode = "INVALID_LOG_LEVEL"
+
+// LogLevels returns the log levels of the instance logger.
+// Changes to the returned levels are applied immediately.
+func (inst *Instance) LogLevels() *logging.Levels {
+	return inst.logAdapter.levels
+}
+
+// SetComponentLogLevel configures the maximum log level for
+// messages of component. See logging.Named.
+func (inst *Instance) SetComponentLogLevel(component string, s logger.Severity) {
+	inst.logAdapter.levels.Set(component, s)
+}
+
+// stepLogLevel increases (up = true) or decreases the default
+// log level by one named level.
+func (inst *Instance) stepLogLevel(up bool) {
+	levels := inst.logAdapter.levels
+
+	level := logging.StepDown(levels.Default())
+	if up {
+		level = logging.StepUp(levels.Default())
+	}
+	levels.SetDefault(level)
+
+	// bypass the level filter so the change is always visible.
+	inst.logAdapter.writeAll(time.Now(), logger.Info, fmt.Sprintf("default log level changed to %s", logging.LevelName(level)), nil)
+}
+
+type logLevelsModel struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+type setLogLevelsModel struct {
+	Levels string `json:"levels" binding:"required"`
+}
+
+type setComponentLogLevelModel struct {
+	Level string `json:"level" binding:"required"`
+}
+
+func (inst *Instance) setupLogLevelRoutes(grp gin.IRouter) {
+	levels := inst.logAdapter.levels
+
+	writeLevels := func(ctx *gin.Context) {
+		model := logLevelsModel{
+			Default:    logging.LevelName(levels.Default()),
+			Components: make(map[string]string),
+		}
+		for name, level := range levels.Components() {
+			model.Components[name] = logging.LevelName(level)
+		}
+
+		ctx.JSON(http.StatusOK, model)
+	}
+
+	grp.GET("/log/levels", writeLevels)
+
+	grp.PUT("/log/levels", func(ctx *gin.Context) {
+		var body setLogLevelsModel
+		if err := server.BindAndValidate(ctx, &body); err != nil {
+			server.AbortRequest(ctx, 0, err)
+			return
+		}
+
+		if err := levels.Parse(body.Levels); err != nil {
+			server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusBadRequest, InvalidLogLevelCode, err.Error(), err))
+			return
+		}
+
+		writeLevels(ctx)
+	})
+
+	grp.PUT("/log/levels/:component", func(ctx *gin.Context) {
+		var body setComponentLogLevelModel
+		if err := server.BindAndValidate(ctx, &body); err != nil {
+			server.AbortRequest(ctx, 0, err)
+			return
+		}
+
+		level, err := logging.ParseLevel(body.Level)
+		if err != nil {
+			server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusBadRequest, InvalidLogLevelCode, err.Error(), err))
+			return
+		}
+
+		levels.Set(ctx.Param("component"), level)
+		writeLevels(ctx)
+	})
+
+	grp.DELETE("/log/levels/:component", func(ctx *gin.Context) {
+		levels.Reset(ctx.Param("component"))
+		writeLevels(ctx)
+	})
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"gotest.tools/assert"
+)
+
+func Test_StepLogLevel(t *testing.T) {
+	var severities []logger.Severity
+	inst := &Instance{logAdapter: newLogAdapter(logging.LevelWarning)}
+	inst.logAdapter.addAdapter(logger.AdapterFunc(func(_ time.Time, s logger.Severity, _ string, _ logger.Fields) {
+		severities = append(severities, s)
+	}))
+
+	inst.stepLogLevel(false)
+
+	// the change is logged at info level even though info
+	// messages are filtered.
+	assert.Equal(t, logging.LevelError, inst.logAdapter.levels.Default())
+	assert.DeepEqual(t, []logger.Severity{logger.Info}, severities)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ppacher/system-conf/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// MetricsConfig configures the Prometheus metrics endpoint of the
+// built-in HTTP server as read from the [Metrics] section.
+type MetricsConfig struct {
+	// Enabled enables the metrics endpoint.
+	Enabled bool
+
+	// Path is the path of the metrics endpoint.
+	Path string
+
+	// Listeners holds the names of all listeners that serve the
+	// metrics endpoint. If empty, all listeners are used.
+	Listeners []string `option:"Listener"`
+}
+
+// MetricsSpec defines the available configuration values for the
+// [Metrics] section.
+var MetricsSpec = conf.SectionSpec{
+	{
+		Name:        "Enabled",
+		Description: "Whether or not the Prometheus metrics endpoint should be enabled. Restrict it to a private listener using Listener.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "Path",
+		Description: "The path of the Prometheus metrics endpoint.",
+		Type:        conf.StringType,
+		Default:     "/metrics",
+	},
+	{
+		Name:        "Listener",
+		Description: "Names of the listeners that serve the metrics endpoint. Defaults to all listeners.",
+		Type:        conf.StringSliceType,
+	},
+}
+
+// Metrics returns the Prometheus registry of the instance. It
+// already contains Go runtime, process, HTTP server and service
+// metrics. Applications may register their own collectors. The
+// registry is only exposed if enabled in the [Metrics] section.
+func (inst *Instance) Metrics() *prometheus.Registry {
+	return inst.metrics.registry
+}
+
+type serviceMetrics struct {
+	registry    *prometheus.Registry
+	reloads     *prometheus.CounterVec
+	lastReload  prometheus.Gauge
+	logMessages *prometheus.CounterVec
+}
+
+func newServiceMetrics() *serviceMetrics {
+	m := &serviceMetrics{
+		registry: prometheus.NewRegistry(),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "service_config_reloads_total",
+			Help: "Total number of configuration reloads by result.",
+		}, []string{"result"}),
+		lastReload: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "service_config_last_reload_success_timestamp_seconds",
+			Help: "Timestamp of the last successful configuration reload.",
+		}),
+		logMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "service_log_messages_total",
+			Help: "Total number of log messages by level.",
+		}, []string{"level"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.reloads,
+		m.lastReload,
+		m.logMessages,
+	)
+
+	return m
+}
+
+// Write implements logger.Adapter and counts log messages.
+func (m *serviceMetrics) Write(_ time.Time, severity logger.Severity, _ string, _ logger.Fields) {
+	m.logMessages.WithLabelValues(logging.LevelName(severity)).Inc()
+}
+
+// reloaded records the result of a configuration reload.
+func (m *serviceMetrics) reloaded(err error) {
+	if err != nil {
+		m.reloads.WithLabelValues("failure").Inc()
+		return
+	}
+	m.reloads.WithLabelValues("success").Inc()
+	m.lastReload.SetToCurrentTime()
+}
+
+func prepareMetrics(cfg *Config, inst *Instance) error {
+	if inst.srv == nil {
+		return nil
+	}
+
+	var file struct {
+		Metrics MetricsConfig `section:"Metrics"`
+	}
+	file.Metrics = MetricsConfig{
+		Path: "/metrics",
+	}
+
+	if err := conf.DecodeFile(inst.cfgFile, &file, cfg); err != nil {
+		return fmt.Errorf("failed to parse metrics section: %w", err)
+	}
+
+	if !file.Metrics.Enabled {
+		return nil
+	}
+
+	handler := promhttp.HandlerFor(inst.metrics.registry, promhttp.HandlerOpts{})
+	inst.handleGET(
+		file.Metrics.Path,
+		server.RequireListener(file.Metrics.Listeners...),
+		gin.WrapH(handler),
+	)
+
+	return nil
+}
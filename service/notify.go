@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/health"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/sdnotify"
+)
+
+// notify sends states to systemd if the service is running as
+// a Type=notify unit. Failures are only logged.
+func notify(states ...string) {
+	if _, err := sdnotify.Notify(states...); err != nil {
+		log := logging.Named(logger.DefaultLogger(), "sdnotify")
+		log.Errorf("failed to notify systemd: %s", err)
+	}
+}
+
+// startWatchdog sends WATCHDOG=1 to systemd at half the interval
+// configured in WATCHDOG_USEC as long as all liveness checks pass.
+// The returned function stops the watchdog.
+func (inst *Instance) startWatchdog() func() {
+	log := logging.Named(logger.DefaultLogger(), "sdnotify")
+
+	interval, err := sdnotify.WatchdogInterval()
+	if err != nil {
+		log.Errorf("watchdog disabled: %s", err)
+		return func() {}
+	}
+	if interval == 0 {
+		return func() {}
+	}
+
+	log.V(5).Logf("sending watchdog notifications every %s", interval/2)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval/2)
+				report := inst.health.Liveness(ctx)
+				cancel()
+
+				// skip the notification so systemd restarts
+				// the service once the watchdog expires.
+				if report.Status == health.StatusFailing {
+					log.Errorf("liveness checks failing, skipping watchdog notification")
+					continue
+				}
+
+				notify(sdnotify.Watchdog)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tierklinik-dobersberg/service/svcenv"
+)
+
+// pidFilePath returns the path of the PID file used for
+// Config.SingleInstance.
+func pidFilePath(cfg *Config, env svcenv.ServiceEnv) string {
+	path := cfg.PIDFile
+	if path == "" {
+		name := strings.TrimSuffix(cfg.ConfigFileName, filepath.Ext(cfg.ConfigFileName))
+		if name == "" {
+			name = filepath.Base(os.Args[0])
+		}
+		path = name + ".pid"
+	}
+
+	if !filepath.IsAbs(path) {
+		// RuntimeDirectory falls back to the shared temporary
+		// directory if it's not set by systemd.
+		dir := env.RuntimeDirectory
+		if os.Getenv("RUNTIME_DIRECTORY") == "" {
+			dir = env.StateDirectory
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	return path
+}
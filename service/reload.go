@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/sdnotify"
+)
+
+// ReloadFunc is called with the new configuration file when the
+// configuration is reloaded. See Instance.OnReload.
+type ReloadFunc func(ctx context.Context, file *conf.File) error
+
+// OnReload registers fn to be called whenever the configuration is
+// reloaded. Hooks are called in the order they are registered and
+// a failing hook aborts the reload.
+func (inst *Instance) OnReload(fn ReloadFunc) {
+	inst.cfgLock.Lock()
+	defer inst.cfgLock.Unlock()
+
+	inst.reloadHooks = append(inst.reloadHooks, fn)
+}
+
+// Reload re-opens the access log, reloads the configuration file,
+// re-applies the log levels of the [Log] section and calls all
+// hooks registered using OnReload. The access log is re-opened
+// even if the new configuration is invalid. Log levels that have
+// been changed at runtime are kept. Settings that require a
+// restart, like listeners or log outputs, are not changed.
+// Config.ConfigTarget is not updated, hooks should decode the new
+// file instead. Reload is called when SIGHUP is received. systemd
+// is notified about the reload using RELOADING=1 and READY=1.
+func (inst *Instance) Reload(ctx context.Context) error {
+	notify(sdnotify.Reloading, sdnotify.Status("reloading configuration"))
+
+	err := inst.reload(ctx)
+	inst.metrics.reloaded(err)
+
+	if err != nil {
+		notify(sdnotify.Ready, sdnotify.Status("failed to reload configuration: %s", err))
+	} else {
+		notify(sdnotify.Ready, sdnotify.Status("serving"))
+	}
+
+	return err
+}
+
+func (inst *Instance) reload(ctx context.Context) error {
+	// re-open the access log first so external log rotation
+	// keeps working even if the new configuration is invalid.
+	if inst.srv != nil {
+		inst.srv.ReopenAccessLog()
+	}
+
+	inst.reloadLock.Lock()
+	defer inst.reloadLock.Unlock()
+
+	cfg := inst.Config
+	cfgFile, err := loadConfig(inst.ServiceEnv, &cfg)
+	if err != nil {
+		return fmt.Errorf("configuration: %w", err)
+	}
+
+	var file struct {
+		Log logging.Config `section:"Log"`
+	}
+	if err := conf.DecodeFile(cfgFile, &file, &cfg); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	levels := inst.logAdapter.levels
+	if err := levels.Reload(cfg.LogLevel, inst.logAdapter.configLevels, file.Log.Level); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	inst.logAdapter.configLevels = file.Log.Level
+
+	inst.cfgLock.Lock()
+	inst.cfgFile = cfgFile
+	hooks := append([]ReloadFunc(nil), inst.reloadHooks...)
+	inst.cfgLock.Unlock()
+
+	for _, fn := range hooks {
+		if err := fn(ctx, cfgFile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
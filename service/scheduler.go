@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ppacher/system-conf/conf"
+	"github.com/tierklinik-dobersberg/logger"
+	"github.com/tierklinik-dobersberg/service/lifecycle"
+	"github.com/tierklinik-dobersberg/service/logging"
+	"github.com/tierklinik-dobersberg/service/scheduler"
+	"github.com/tierklinik-dobersberg/service/server"
+)
+
+// JobNotFoundCode is used as the problem code if an unknown job
+// is triggered using the administrative job endpoints.
+const JobNotFoundCode = "JOB_NOT_FOUND"
+
+// JobRunningCode is used as the problem code if a job that
+// prevents overlapping executions is triggered while it's
+// still running.
+const JobRunningCode = "JOB_RUNNING"
+
+// Scheduler returns the job scheduler of the instance. It's
+// started and stopped by Serve and jobs may be added at any time.
+// Jobs from [Job] sections execute handlers registered using
+// Scheduler().Handle. A headless instance is only kept running by
+// the scheduler if jobs or handlers have been added before Serve.
+func (inst *Instance) Scheduler() *scheduler.Scheduler {
+	return inst.scheduler
+}
+
+func prepareScheduler(cfg *Config, inst *Instance) error {
+	var file struct {
+		Jobs []scheduler.Config `section:"Job"`
+	}
+	if err := conf.DecodeFile(inst.cfgFile, &file, cfg); err != nil {
+		return fmt.Errorf("failed to parse job sections: %w", err)
+	}
+
+	inst.scheduler = scheduler.New(
+		logging.Named(logger.DefaultLogger(), "scheduler"),
+		filepath.Join(inst.StateDirectory, scheduler.StateFileName),
+	)
+	for _, job := range file.Jobs {
+		if err := inst.scheduler.AddConfig(job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startComponents starts all components. The scheduler is always
+// started so jobs can be added later on but it only keeps a
+// headless instance running if there's something to schedule.
+func (inst *Instance) startComponents(ctx context.Context) error {
+	var opts []lifecycle.Option
+	if inst.scheduler.Empty() {
+		opts = append(opts, lifecycle.Background())
+	}
+	if err := inst.AddComponent("scheduler", inst.scheduler, opts...); err != nil {
+		return err
+	}
+
+	return inst.lifecycle.Start(ctx)
+}
+
+type jobsModel struct {
+	Jobs []scheduler.JobStatus `json:"jobs"`
+}
+
+func (inst *Instance) setupSchedulerRoutes(grp gin.IRouter) {
+	grp.GET("/jobs", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, jobsModel{
+			Jobs: inst.scheduler.Status(),
+		})
+	})
+
+	grp.POST("/jobs/:name/run", func(ctx *gin.Context) {
+		err := inst.scheduler.Run(ctx.Param("name"))
+		switch {
+		case errors.Is(err, scheduler.ErrJobNotFound):
+			server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusNotFound, JobNotFoundCode, err.Error(), err))
+			return
+		case errors.Is(err, scheduler.ErrJobRunning):
+			server.AbortRequest(ctx, 0, server.NewHTTPError(http.StatusConflict, JobRunningCode, err.Error(), err))
+			return
+		case err != nil:
+			server.AbortRequest(ctx, 0, err)
+			return
+		}
+
+		ctx.Status(http.StatusAccepted)
+	})
+}
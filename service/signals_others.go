@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package service
+
+// handleSignals is a no-op as SIGHUP, SIGUSR1 and SIGUSR2 are
+// not available on this platform.
+func (inst *Instance) handleSignals() func() {
+	return func() {}
+}
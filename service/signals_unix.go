@@ -0,0 +1,50 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tierklinik-dobersberg/logger"
+)
+
+// handleSignals re-opens the access log and reloads the
+// configuration whenever SIGHUP is received and steps the default
+// log level on SIGUSR1 and SIGUSR2 until the returned function is
+// called.
+func (inst *Instance) handleSignals() func() {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				switch sig {
+				case syscall.SIGHUP:
+					log := logger.DefaultLogger()
+					log.Info("received SIGHUP, re-opening access log and reloading configuration")
+					if err := inst.Reload(context.Background()); err != nil {
+						log.Errorf("failed to reload configuration: %s", err)
+					}
+				case syscall.SIGUSR1:
+					inst.stepLogLevel(true)
+				case syscall.SIGUSR2:
+					inst.stepLogLevel(false)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
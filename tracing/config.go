@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"github.com/ppacher/system-conf/conf"
+)
+
+// Supported values for the Exporter option of the [Tracing]
+// section.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Config holds the tracing configuration as read from the
+// [Tracing] section.
+type Config struct {
+	// Exporter is the name of the span exporter. See ExporterNone,
+	// ExporterOTLP, ExporterStdout and ExporterFile.
+	Exporter string
+
+	// ServiceName is reported as the service.name resource
+	// attribute.
+	ServiceName string
+
+	// Endpoint is the host:port of the OTLP/HTTP collector.
+	Endpoint string
+
+	// URLPath is the URL path of the OTLP/HTTP collector.
+	URLPath string
+
+	// Insecure disables TLS for the OTLP/HTTP collector.
+	Insecure bool
+
+	// Headers holds additional HTTP headers sent to the OTLP/HTTP
+	// collector in the format Name=Value.
+	Headers []string `option:"Header"`
+
+	// File is the path of the file spans are written to if
+	// Exporter is set to ExporterFile.
+	File string
+
+	// SampleRatio is the ratio of new traces that are sampled.
+	// Traces started by other services are sampled if the parent
+	// span is sampled.
+	SampleRatio float64
+}
+
+// ConfigSpec defines the available configuration values for the
+// [Tracing] section.
+var ConfigSpec = conf.SectionSpec{
+	{
+		Name:        "Exporter",
+		Description: "The span exporter to use. One of none, otlp, stdout or file.",
+		Type:        conf.StringType,
+		Default:     ExporterNone,
+	},
+	{
+		Name:        "ServiceName",
+		Description: "The service name reported to the tracing backend. Defaults to the name of the executable.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "Endpoint",
+		Description: "The host:port of the OTLP/HTTP collector.",
+		Type:        conf.StringType,
+		Default:     "localhost:4318",
+	},
+	{
+		Name:        "URLPath",
+		Description: "The URL path of the OTLP/HTTP collector.",
+		Type:        conf.StringType,
+		Default:     "/v1/traces",
+	},
+	{
+		Name:        "Insecure",
+		Description: "Whether or not TLS should be disabled for the OTLP/HTTP collector.",
+		Type:        conf.BoolType,
+		Default:     "no",
+	},
+	{
+		Name:        "Header",
+		Description: "Additional HTTP headers sent to the OTLP/HTTP collector in the format Name=Value.",
+		Type:        conf.StringSliceType,
+	},
+	{
+		Name:        "File",
+		Description: "Path to the file spans are written to if Exporter=file.",
+		Type:        conf.StringType,
+	},
+	{
+		Name:        "SampleRatio",
+		Description: "The ratio of new traces that are sampled, between 0 and 1.",
+		Type:        conf.FloatType,
+		Default:     "1",
+	},
+}
@@ -0,0 +1,180 @@
+// Package tracing configures OpenTelemetry tracing and provides
+// helpers to propagate traces across HTTP requests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer used by this module.
+const TracerName = "github.com/tierklinik-dobersberg/service"
+
+// ShutdownFunc flushes all pending spans and stops the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup configures the global OpenTelemetry tracer provider and the
+// W3C trace context propagator as configured in cfg. If no exporter
+// is configured, only the propagator is installed so incoming trace
+// contexts are still forwarded. The returned ShutdownFunc should be
+// called before the service exits.
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	name := cfg.ServiceName
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(name)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return nil, nil
+
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("exporter %s requires File", ExporterFile)
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return &fileExporter{
+			Exporter: mustStdout(stdouttrace.New(stdouttrace.WithWriter(f))),
+			file:     f,
+		}, nil
+
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.Endpoint),
+		}
+		if cfg.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			headers := make(map[string]string, len(cfg.Headers))
+			for _, h := range cfg.Headers {
+				kv := strings.SplitN(h, "=", 2)
+				if len(kv) != 2 {
+					return nil, fmt.Errorf("invalid header %q, expected Name=Value", h)
+				}
+				headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+
+	default:
+		return nil, fmt.Errorf("unsupported exporter %q", cfg.Exporter)
+	}
+}
+
+// fileExporter closes the underlying file on shutdown.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	if cerr := e.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func mustStdout(e *stdouttrace.Exporter, err error) *stdouttrace.Exporter {
+	// stdouttrace.New only fails for invalid options.
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Transport is a http.RoundTripper that starts a client span for
+// each request and injects the trace context into the request
+// headers.
+type Transport struct {
+	// Base is the http.RoundTripper used to send requests.
+	// It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := otel.Tracer(TracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(req)...),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the request.
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(res.StatusCode)...)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(res.StatusCode))
+
+	return res, nil
+}
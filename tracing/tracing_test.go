@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"gotest.tools/assert"
+)
+
+func Test_SetupFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	shutdown, err := Setup(context.Background(), Config{
+		Exporter:    ExporterFile,
+		File:        path,
+		ServiceName: "test-service",
+		SampleRatio: 1,
+	})
+	assert.NilError(t, err)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, span := otel.Tracer(TracerName).Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+	assert.NilError(t, err)
+
+	client := &http.Client{Transport: new(Transport)}
+	res, err := client.Do(req)
+	assert.NilError(t, err)
+	res.Body.Close()
+	span.End()
+
+	assert.Assert(t, strings.HasPrefix(traceparent, "00-"+span.SpanContext().TraceID().String()+"-"))
+	assert.Equal(t, "", req.Header.Get("traceparent"))
+
+	assert.NilError(t, shutdown(context.Background()))
+
+	blob, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(blob), `"Name":"parent"`))
+	assert.Assert(t, strings.Contains(string(blob), `"Name":"HTTP GET"`))
+	assert.Assert(t, strings.Contains(string(blob), "test-service"))
+}
+
+func Test_SetupInvalid(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.ErrorContains(t, err, "unsupported exporter")
+
+	_, err = Setup(context.Background(), Config{Exporter: ExporterFile})
+	assert.ErrorContains(t, err, "requires File")
+
+	_, err = Setup(context.Background(), Config{Exporter: ExporterOTLP, Headers: []string{"invalid"}})
+	assert.ErrorContains(t, err, "invalid header")
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"fmt"
 	"net"
+	"strings"
 
 	"github.com/tierklinik-dobersberg/logger"
 )
@@ -11,10 +13,31 @@ import (
 type IPNetworks []net.IPNet
 
 // ParseNetworks parses a slice of IP CIDR network definitions.
+// Plain IP addresses are accepted as well and treated as single-host
+// networks.
 func ParseNetworks(nets []string) (IPNetworks, error) {
 	result := make([]net.IPNet, len(nets))
 
 	for idx, n := range nets {
+		if !strings.Contains(n, "/") {
+			ip := ParseIP(n)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %q", n)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = 8 * net.IPv4len
+			}
+
+			result[idx] = net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			}
+			continue
+		}
+
 		_, ipnet, err := net.ParseCIDR(n)
 		if err != nil {
 			return nil, err
@@ -53,6 +76,10 @@ func (nets IPNetworks) ContainsString(ip string) bool {
 // ParseIP is like net.ParseIP but accepts that ip may be
 // enclosed in [].
 func ParseIP(s string) net.IP {
+	if s == "" {
+		return nil
+	}
+
 	if s[0] == '[' && s[len(s)-1] == ']' {
 		s = s[1 : len(s)-1]
 	}
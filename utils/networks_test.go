@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_ParseNetworks(t *testing.T) {
+	nets, err := ParseNetworks([]string{"10.0.0.0/8", "192.168.1.1", "[2001:db8::1]", "fd00::/8"})
+	assert.NilError(t, err)
+	assert.Equal(t, 4, len(nets))
+
+	cases := []struct {
+		I string
+		O bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.1", true},
+		{"192.168.1.2", false},
+		{"2001:db8::1", true},
+		{"2001:db8::2", false},
+		{"fd12::1", true},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.O, nets.ContainsString(c.I), c.I)
+	}
+
+	_, err = ParseNetworks([]string{"10.0.0.0/33"})
+	assert.Assert(t, err != nil)
+
+	_, err = ParseNetworks([]string{"not-an-ip"})
+	assert.Assert(t, err != nil)
+}